@@ -0,0 +1,74 @@
+package console
+
+import (
+	"encoding/json"
+	"io"
+	"os/user"
+	"sync"
+	"time"
+)
+
+// AuditEntry describes a single command execution.
+type AuditEntry struct {
+	Time     time.Time     `json:"time"`
+	User     string        `json:"user"`
+	Input    string        `json:"input"`
+	Command  string        `json:"command"`
+	Duration time.Duration `json:"duration_ns"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// AuditLogger records executed commands.
+type AuditLogger interface {
+	Audit(e AuditEntry) error
+}
+
+// WithAuditLog writes an audit entry as JSON line to w for every executed command.
+func WithAuditLog(w io.Writer) Opts {
+	return WithAuditLogger(&jsonAuditLogger{enc: json.NewEncoder(w)})
+}
+
+// WithAuditLogger passes an audit entry to l for every executed command.
+func WithAuditLogger(l AuditLogger) Opts {
+	return func(c *Console) {
+		c.audit = l
+	}
+}
+
+type jsonAuditLogger struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (l *jsonAuditLogger) Audit(e AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.enc.Encode(e)
+}
+
+func currentUser() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	return u.Username
+}
+
+func (c *Console) auditLog(input string, cmd *Cmd, start time.Time, err error) {
+	if c.audit == nil {
+		return
+	}
+	e := AuditEntry{
+		Time:     start,
+		User:     c.identity.Name,
+		Input:    input,
+		Command:  cmd.Name,
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	if err := c.audit.Audit(e); err != nil {
+		c.logger.Error("error writing audit log", "err", err)
+	}
+}
@@ -0,0 +1,114 @@
+package console
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrUnauthenticated = errors.New("authentication failed")
+
+// Identity is the authenticated user of a console session.
+type Identity struct {
+	Name  string
+	Roles []string
+}
+
+// HasRole reports whether the identity has one of the given roles.
+func (i Identity) HasRole(roles ...string) bool {
+	for _, r := range i.Roles {
+		for _, role := range roles {
+			if r == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AuthFunc authenticates the user of a console session.
+type AuthFunc func(c *Console) (Identity, error)
+
+// WithAuth authenticates the user before the prompt loop is started.
+// If authentication fails, Start returns the error and the console is not started.
+func WithAuth(auth AuthFunc) Opts {
+	return func(c *Console) {
+		c.auth = auth
+	}
+}
+
+// PasswordAuth prompts for a username and a password and passes them to verify.
+func PasswordAuth(verify func(user, password string) (Identity, error)) AuthFunc {
+	return func(c *Console) (Identity, error) {
+		user, err := c.ReadLine(c.msg(MsgUsername))
+		if err != nil {
+			return Identity{}, err
+		}
+		password, err := c.ReadPassword(c.msg(MsgPassword))
+		if err != nil {
+			return Identity{}, err
+		}
+		return verify(user, password)
+	}
+}
+
+// TokenAuth authenticates the user with a token, e.g. read from the environment.
+func TokenAuth(token func() (string, error), verify func(token string) (Identity, error)) AuthFunc {
+	return func(c *Console) (Identity, error) {
+		t, err := token()
+		if err != nil {
+			return Identity{}, err
+		}
+		return verify(t)
+	}
+}
+
+// RequireRole returns a permission check allowing identities with one of the given roles.
+func RequireRole(roles ...string) func(c *Console) bool {
+	return func(c *Console) bool {
+		return c.Identity().HasRole(roles...)
+	}
+}
+
+// WithIdentity sets the identity of the session user,
+// e.g. if the user was already authenticated by the transport.
+func WithIdentity(id Identity) Opts {
+	return func(c *Console) {
+		c.identity = id
+	}
+}
+
+// Identity returns the identity of the session user.
+// Without authentication, the name of the OS user is used.
+// While privileges are elevated, the elevated role is included.
+func (c *Console) Identity() Identity {
+	if c.elevation == nil || !c.Elevated() {
+		return c.identity
+	}
+	id := c.identity
+	id.Roles = append(append([]string(nil), id.Roles...), c.elevation.Role)
+	return id
+}
+
+// ReadLine prompts the user for a line of input.
+func (c *Console) ReadLine(prompt string) (string, error) {
+	return c.reader.Prompt(prompt)
+}
+
+// ReadPassword prompts the user for a line of input without echoing it.
+func (c *Console) ReadPassword(prompt string) (string, error) {
+	return c.reader.PasswordPrompt(prompt)
+}
+
+func (c *Console) authenticate() error {
+	if c.auth == nil {
+		return nil
+	}
+	id, err := c.auth(c)
+	if err != nil {
+		c.logger.Warn("authentication failed", "err", err)
+		return fmt.Errorf("%w: %s", ErrUnauthenticated, err)
+	}
+	c.logger.Info("authenticated", "user", id.Name)
+	c.identity = id
+	return nil
+}
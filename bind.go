@@ -0,0 +1,464 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errHelp is returned by bind if the usage was requested with --help.
+var errHelp = errors.New("help requested")
+
+// binding binds command line arguments to the fields of a struct.
+//
+// Fields are bound by their tags:
+//
+//	flag:"name"      flag set with --name value, --name=value or -s value
+//	short:"s"        short name of the flag
+//	arg:"name"       positional argument, a slice takes the remaining arguments
+//	help:"..."       description
+//	default:"..."    default value
+//	required:"true"  the flag or argument must be set
+//	enum:"a,b,c"     permitted values, offered as completion
+type binding struct {
+	typ   reflect.Type
+	flags []*field
+	args  []*field
+}
+
+type field struct {
+	index    int
+	name     string
+	short    string
+	help     string
+	def      string
+	required bool
+	enum     []string
+	typ      reflect.Type
+}
+
+func (f *field) isBool() bool {
+	return f.typ.Kind() == reflect.Bool
+}
+
+// newBinding creates the binding of the struct type t. If untagged is set,
+// exported fields without tags are bound as flags named after the field.
+func newBinding(t reflect.Type, untagged bool) (*binding, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s is not a struct", t)
+	}
+	b := &binding{typ: t}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		flagName, isFlag := sf.Tag.Lookup("flag")
+		argName, isArg := sf.Tag.Lookup("arg")
+		if !isFlag && !isArg {
+			if !untagged || !sf.IsExported() || sf.Anonymous {
+				continue
+			}
+			isFlag = true
+		}
+		if !sf.IsExported() {
+			return nil, fmt.Errorf("field %s of %s is not exported", sf.Name, t)
+		}
+		if !supportedKind(sf.Type) {
+			return nil, fmt.Errorf("field %s of %s has unsupported type %s", sf.Name, t, sf.Type)
+		}
+		f := &field{
+			index:    i,
+			short:    sf.Tag.Get("short"),
+			help:     sf.Tag.Get("help"),
+			def:      sf.Tag.Get("default"),
+			required: sf.Tag.Get("required") == "true",
+			typ:      sf.Type,
+		}
+		if e := sf.Tag.Get("enum"); e != "" {
+			f.enum = strings.Split(e, ",")
+		}
+		if isFlag {
+			f.name = flagName
+			if f.name == "" {
+				f.name = kebabCase(sf.Name)
+			}
+			b.flags = append(b.flags, f)
+			continue
+		}
+		f.name = argName
+		if f.name == "" {
+			f.name = kebabCase(sf.Name)
+		}
+		if n := len(b.args); n > 0 && b.args[n-1].typ.Kind() == reflect.Slice {
+			return nil, fmt.Errorf("argument %s of %s follows a slice argument", f.name, t)
+		}
+		b.args = append(b.args, f)
+	}
+	return b, nil
+}
+
+func supportedKind(t reflect.Type) bool {
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Slice && supportedKind(t.Elem())
+	}
+	return false
+}
+
+// wire sets the handler, completer and args of cmd. The handler binds
+// the arguments to a copy of template and passes it to run.
+func (b *binding) wire(cmd *Cmd, run func(ctx context.Context, c *Console, v reflect.Value) error, template reflect.Value) {
+	cmd.Args = nil
+	for _, a := range b.args {
+		cmd.Args = append(cmd.Args, &Arg{Name: a.name, Description: a.help, Values: a.enum})
+	}
+	cmd.Completer = func(_ *Console, args []string) []string {
+		return b.complete(args)
+	}
+	cmd.binding = b
+	cmd.Handler = nil
+	cmd.ContextHandler = func(ctx context.Context, c *Console, args []string) error {
+		v := reflect.New(b.typ)
+		v.Elem().Set(template)
+		if err := b.bind(v, args); err != nil {
+			if errors.Is(err, errHelp) {
+				fmt.Fprintln(c.Writer(ctx), b.usage(c, cmd.Name))
+				return nil
+			}
+			return fmt.Errorf("%w\n%s", err, b.usage(c, cmd.Name))
+		}
+		return run(ctx, c, v)
+	}
+}
+
+// Bind sets the handler of cmd to fn. The flags and positional arguments
+// of an invocation are bound to a new T, which must be a struct, like
+// the fields of a struct command, see StructCmd. Exported fields without
+// tags are bound as flags named after the field, so
+//
+//	type Params struct {
+//		DryRun bool
+//		Target string `arg:"target" required:"true"`
+//	}
+//
+// accepts --dry-run and a target. If T can't be bound, registering cmd fails.
+func Bind[T any](cmd *Cmd, fn func(c *Console, params *T) error) *Cmd {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	b, err := newBinding(t, true)
+	if err != nil {
+		cmd.bindErr = fmt.Errorf("command %s: %w", cmd.Name, err)
+		return cmd
+	}
+	b.wire(cmd, func(_ context.Context, c *Console, v reflect.Value) error {
+		return fn(c, v.Interface().(*T))
+	}, reflect.Zero(t))
+	return cmd
+}
+
+// bind parses args into v, a pointer to a struct of the bound type.
+func (b *binding) bind(v reflect.Value, args []string) error {
+	v = v.Elem()
+	set := make(map[*field]bool)
+	for _, f := range append(append([]*field(nil), b.flags...), b.args...) {
+		if f.def != "" {
+			if err := setValue(v.Field(f.index), f.def); err != nil {
+				return fmt.Errorf("invalid default of %s: %w", f.name, err)
+			}
+		}
+	}
+
+	var positionals []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			positionals = append(positionals, args[i+1:]...)
+			break
+		}
+		if a == "--help" || a == "-h" {
+			return errHelp
+		}
+		if !strings.HasPrefix(a, "-") || a == "-" {
+			positionals = append(positionals, a)
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(a, "-"), "=")
+		f := b.flag(name, !strings.HasPrefix(a, "--"))
+		if f == nil {
+			return fmt.Errorf("unknown flag %s", a)
+		}
+		if !hasValue {
+			if f.isBool() {
+				value = "true"
+			} else {
+				if i+1 >= len(args) {
+					return fmt.Errorf("flag %s needs a value", a)
+				}
+				i++
+				value = args[i]
+			}
+		}
+		if f.typ.Kind() == reflect.Slice && !set[f] {
+			// the values of a repeated flag replace the default instead of extending it
+			v.Field(f.index).Set(reflect.Zero(f.typ))
+		}
+		if err := f.set(v, value); err != nil {
+			return err
+		}
+		set[f] = true
+	}
+
+	for i, f := range b.args {
+		if i >= len(positionals) {
+			break
+		}
+		if f.typ.Kind() == reflect.Slice {
+			v.Field(f.index).Set(reflect.Zero(f.typ))
+			for _, p := range positionals[i:] {
+				if err := f.set(v, p); err != nil {
+					return err
+				}
+			}
+			positionals = positionals[:i+1]
+		} else if err := f.set(v, positionals[i]); err != nil {
+			return err
+		}
+		set[f] = true
+	}
+	if len(positionals) > len(b.args) {
+		return fmt.Errorf("too many arguments")
+	}
+
+	for _, f := range b.flags {
+		if f.required && !set[f] {
+			return fmt.Errorf("flag --%s is required", f.name)
+		}
+	}
+	for _, f := range b.args {
+		if f.required && !set[f] {
+			return fmt.Errorf("argument <%s> is required", f.name)
+		}
+	}
+	return nil
+}
+
+func (b *binding) flag(name string, short bool) *field {
+	for _, f := range b.flags {
+		if (short && f.short == name) || (!short && f.name == name) {
+			return f
+		}
+	}
+	return nil
+}
+
+func (f *field) set(v reflect.Value, s string) error {
+	if len(f.enum) > 0 && !contains(f.enum, s) {
+		return fmt.Errorf("invalid value %q for %s, must be one of %s", s, f.name, strings.Join(f.enum, ", "))
+	}
+	fv := v.Field(f.index)
+	if fv.Kind() == reflect.Slice {
+		ev := reflect.New(fv.Type().Elem()).Elem()
+		if err := setValue(ev, s); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %w", s, f.name, err)
+		}
+		fv.Set(reflect.Append(fv, ev))
+		return nil
+	}
+	if err := setValue(fv, s); err != nil {
+		return fmt.Errorf("invalid value %q for %s: %w", s, f.name, err)
+	}
+	return nil
+}
+
+func setValue(v reflect.Value, s string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		// a default of a slice is a comma separated list
+		v.Set(reflect.MakeSlice(v.Type(), 0, 0))
+		for _, p := range strings.Split(s, ",") {
+			ev := reflect.New(v.Type().Elem()).Elem()
+			if err := setValue(ev, p); err != nil {
+				return err
+			}
+			v.Set(reflect.Append(v, ev))
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func (b *binding) usage(c *Console, name string) string {
+	s := c.msg(MsgUsage, name)
+	if len(b.flags) > 0 {
+		s += " [flags]"
+	}
+	for _, a := range b.args {
+		n := "<" + a.name + ">"
+		if a.typ.Kind() == reflect.Slice {
+			n += "..."
+		}
+		if !a.required {
+			n = "[" + n + "]"
+		}
+		s += " " + n
+	}
+	if len(b.args) > 0 {
+		s += "\n\n" + c.msg(MsgUsageArguments)
+		for _, a := range b.args {
+			s += fmt.Sprintf("\n  %-20s %s", a.name, a.description(c))
+		}
+	}
+	if len(b.flags) > 0 {
+		s += "\n\n" + c.msg(MsgUsageFlags)
+		for _, f := range b.flags {
+			n := "    --" + f.name
+			if f.short != "" {
+				n = "-" + f.short + ", --" + f.name
+			}
+			if !f.isBool() {
+				n += " " + typeName(f.typ)
+			}
+			s += fmt.Sprintf("\n  %-20s %s", n, f.description(c))
+		}
+	}
+	return s
+}
+
+func (f *field) description(c *Console) string {
+	s := f.help
+	if len(f.enum) > 0 {
+		s += " " + c.msg(MsgOneOf, strings.Join(f.enum, ", "))
+	}
+	if f.def != "" {
+		s += " " + c.msg(MsgDefault, f.def)
+	}
+	if f.required {
+		s += " " + c.msg(MsgRequired)
+	}
+	return strings.TrimSpace(s)
+}
+
+func typeName(t reflect.Type) string {
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return "duration"
+	}
+	if t.Kind() == reflect.Slice {
+		return typeName(t.Elem())
+	}
+	return t.Kind().String()
+}
+
+// complete completes the last of the given arguments.
+func (b *binding) complete(args []string) (s []string) {
+	cur := args[len(args)-1]
+	prev := args[:len(args)-1]
+	if n := len(prev); n > 0 && strings.HasPrefix(prev[n-1], "-") && !strings.Contains(prev[n-1], "=") {
+		if f := b.flag(strings.TrimLeft(prev[n-1], "-"), !strings.HasPrefix(prev[n-1], "--")); f != nil && !f.isBool() {
+			return withPrefix(f.enum, cur)
+		}
+	}
+	if strings.HasPrefix(cur, "-") {
+		for _, f := range b.flags {
+			if n := "--" + f.name; strings.HasPrefix(n, cur) {
+				s = append(s, n)
+			}
+		}
+		return s
+	}
+	// count the positionals before the current argument
+	i := 0
+	for j := 0; j < len(prev); j++ {
+		if strings.HasPrefix(prev[j], "-") {
+			if f := b.flag(strings.TrimLeft(prev[j], "-"), !strings.HasPrefix(prev[j], "--")); f != nil && !f.isBool() && !strings.Contains(prev[j], "=") {
+				j++
+			}
+			continue
+		}
+		i++
+	}
+	if i < len(b.args) {
+		return withPrefix(b.args[i].enum, cur)
+	}
+	if n := len(b.args); n > 0 && b.args[n-1].typ.Kind() == reflect.Slice {
+		return withPrefix(b.args[n-1].enum, cur)
+	}
+	return nil
+}
+
+func withPrefix(values []string, prefix string) (s []string) {
+	for _, v := range values {
+		if strings.HasPrefix(v, prefix) {
+			s = append(s, v)
+		}
+	}
+	return
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// kebabCase converts a Go identifier like ListUsers to list-users.
+func kebabCase(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		upper := r >= 'A' && r <= 'Z'
+		if upper && i > 0 && (runes[i-1] < 'A' || runes[i-1] > 'Z' || (i+1 < len(runes) && runes[i+1] >= 'a' && runes[i+1] <= 'z')) {
+			b.WriteByte('-')
+		}
+		if upper {
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
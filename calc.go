@@ -0,0 +1,284 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ResultKey is the session key the result of the calc command and the data of rendered Results are stored in.
+const ResultKey = "_"
+
+var errDivisionByZero = errors.New("division by zero")
+
+var calcFuncs = map[string]func(args ...float64) (float64, error){
+	"abs":   unary(math.Abs),
+	"sqrt":  unary(math.Sqrt),
+	"floor": unary(math.Floor),
+	"ceil":  unary(math.Ceil),
+	"round": unary(math.Round),
+	"min":   variadic(math.Min),
+	"max":   variadic(math.Max),
+}
+
+func unary(fn func(float64) float64) func(args ...float64) (float64, error) {
+	return func(args ...float64) (float64, error) {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("expected 1 argument, got %d", len(args))
+		}
+		return fn(args[0]), nil
+	}
+}
+
+func variadic(fn func(a, b float64) float64) func(args ...float64) (float64, error) {
+	return func(args ...float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, errors.New("expected at least 1 argument")
+		}
+		v := args[0]
+		for _, a := range args[1:] {
+			v = fn(v, a)
+		}
+		return v, nil
+	}
+}
+
+// Eval evaluates an arithmetic expression. It supports + - * / % ^,
+// parentheses, the functions abs, sqrt, floor, ceil, round, min and max,
+// and session values referenced by name or $name. $? is the exit code of
+// the last process run by Exec.
+func (c *Console) Eval(expr string) (float64, error) {
+	p := &exprParser{src: []rune(expr), vars: c.numericValue}
+	return p.parse()
+}
+
+func (c *Console) numericValue(name string) (float64, error) {
+	v, ok := c.Get(name)
+	if !ok {
+		return 0, fmt.Errorf("undefined variable %s", name)
+	}
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("variable %s is not a number", name)
+}
+
+// exprParser is a recursive descent parser evaluating while parsing.
+//
+//	expr   = term { ("+" | "-") term }
+//	term   = unary { ("*" | "/" | "%") unary }
+//	unary  = "-" unary | power
+//	power  = atom [ "^" unary ]
+//	atom   = number | name [ "(" expr { "," expr } ")" ] | "(" expr ")"
+type exprParser struct {
+	src  []rune
+	pos  int
+	vars func(name string) (float64, error)
+}
+
+func (p *exprParser) parse() (float64, error) {
+	v, err := p.expr()
+	if err != nil {
+		return 0, err
+	}
+	if p.skip(); p.pos < len(p.src) {
+		return 0, fmt.Errorf("unexpected %q at position %d", p.src[p.pos], p.pos+1)
+	}
+	return v, nil
+}
+
+func (p *exprParser) skip() {
+	for p.pos < len(p.src) && unicode.IsSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+// accept consumes r if it's the next rune.
+func (p *exprParser) accept(r rune) bool {
+	if p.skip(); p.pos < len(p.src) && p.src[p.pos] == r {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) expr() (float64, error) {
+	v, err := p.term()
+	for err == nil {
+		var w float64
+		switch {
+		case p.accept('+'):
+			w, err = p.term()
+			v += w
+		case p.accept('-'):
+			w, err = p.term()
+			v -= w
+		default:
+			return v, nil
+		}
+	}
+	return 0, err
+}
+
+func (p *exprParser) term() (float64, error) {
+	v, err := p.unary()
+	for err == nil {
+		var w float64
+		switch {
+		case p.accept('*'):
+			w, err = p.unary()
+			v *= w
+		case p.accept('/'):
+			if w, err = p.unary(); err == nil && w == 0 {
+				err = errDivisionByZero
+			}
+			v /= w
+		case p.accept('%'):
+			if w, err = p.unary(); err == nil && w == 0 {
+				err = errDivisionByZero
+			}
+			v = math.Mod(v, w)
+		default:
+			return v, nil
+		}
+	}
+	return 0, err
+}
+
+func (p *exprParser) unary() (float64, error) {
+	if p.accept('-') {
+		v, err := p.unary()
+		return -v, err
+	}
+	return p.power()
+}
+
+func (p *exprParser) power() (float64, error) {
+	v, err := p.atom()
+	if err != nil {
+		return 0, err
+	}
+	if p.accept('^') {
+		e, err := p.unary()
+		if err != nil {
+			return 0, err
+		}
+		return math.Pow(v, e), nil
+	}
+	return v, nil
+}
+
+func (p *exprParser) atom() (float64, error) {
+	if p.accept('(') {
+		v, err := p.expr()
+		if err != nil {
+			return 0, err
+		}
+		if !p.accept(')') {
+			return 0, errors.New("missing )")
+		}
+		return v, nil
+	}
+	p.skip()
+	if p.pos >= len(p.src) {
+		return 0, errors.New("unexpected end of expression")
+	}
+	start := p.pos
+	switch r := p.src[p.pos]; {
+	case unicode.IsDigit(r) || r == '.':
+		for p.pos < len(p.src) && (unicode.IsDigit(p.src[p.pos]) || p.src[p.pos] == '.' ||
+			p.src[p.pos] == 'e' || p.src[p.pos] == 'E' ||
+			((p.src[p.pos] == '+' || p.src[p.pos] == '-') && (p.src[p.pos-1] == 'e' || p.src[p.pos-1] == 'E'))) {
+			p.pos++
+		}
+		v, err := strconv.ParseFloat(string(p.src[start:p.pos]), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %s", string(p.src[start:p.pos]))
+		}
+		return v, nil
+	case r == '$' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '?':
+		p.pos += 2
+		return p.vars(ExitCodeKey)
+	case r == '$' || r == '_' || unicode.IsLetter(r):
+		p.pos++
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || unicode.IsLetter(p.src[p.pos]) || unicode.IsDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		name := strings.TrimPrefix(string(p.src[start:p.pos]), "$")
+		if p.accept('(') {
+			return p.call(name)
+		}
+		return p.vars(name)
+	default:
+		return 0, fmt.Errorf("unexpected %q at position %d", r, p.pos+1)
+	}
+}
+
+func (p *exprParser) call(name string) (float64, error) {
+	fn, ok := calcFuncs[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown function %s", name)
+	}
+	var args []float64
+	if !p.accept(')') {
+		for {
+			v, err := p.expr()
+			if err != nil {
+				return 0, err
+			}
+			args = append(args, v)
+			if p.accept(')') {
+				break
+			}
+			if !p.accept(',') {
+				return 0, errors.New("missing )")
+			}
+		}
+	}
+	v, err := fn(args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return v, nil
+}
+
+var calcCmd = &Cmd{
+	Name:        "calc",
+	Aliases:     []string{"="},
+	Description: "Evaluate an arithmetic expression",
+	descID:      MsgCalcDescription,
+	builtin:     true,
+	ContextHandler: func(ctx context.Context, c *Console, args []string) error {
+		if len(args) == 0 {
+			return errors.New(c.msg(MsgUsageError, "calc <expression>"))
+		}
+		v, err := c.Eval(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		c.Set(ResultKey, v)
+		fmt.Fprintln(c.Writer(ctx), strconv.FormatFloat(v, 'g', -1, 64))
+		return nil
+	},
+}
@@ -0,0 +1,163 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// CatalogCmd describes a command for other programs, see Catalog.
+type CatalogCmd struct {
+	Name        string        `json:"name"`
+	Aliases     []string      `json:"aliases,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Group       string        `json:"group,omitempty"`
+	LongHelp    string        `json:"long_help,omitempty"`
+	Examples    []string      `json:"examples,omitempty"`
+	SeeAlso     []string      `json:"see_also,omitempty"`
+	Args        []CatalogArg  `json:"args,omitempty"`
+	Flags       []CatalogFlag `json:"flags,omitempty"`
+	Danger      string        `json:"danger"`
+	// Restricted is set if the command has a Permission.
+	Restricted bool `json:"restricted"`
+	// Permitted reports whether the session user may run the command.
+	Permitted      bool `json:"permitted"`
+	Builtin        bool `json:"builtin"`
+	SupportsDryRun bool `json:"supports_dry_run,omitempty"`
+	// Lazy is set for commands of RegisterLazy which weren't loaded yet,
+	// so their arguments aren't known.
+	Lazy bool `json:"lazy,omitempty"`
+	// Commands are the commands of a namespace.
+	Commands []CatalogCmd `json:"commands,omitempty"`
+}
+
+// CatalogArg describes a positional argument.
+type CatalogArg struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Values      []string `json:"values,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	// Variadic arguments take the remaining arguments.
+	Variadic bool `json:"variadic,omitempty"`
+}
+
+// CatalogFlag describes a flag of a command with bound parameters, see Bind.
+type CatalogFlag struct {
+	Name        string   `json:"name"`
+	Short       string   `json:"short,omitempty"`
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Default     string   `json:"default,omitempty"`
+	Values      []string `json:"values,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+}
+
+// Catalog describes all commands available in the current mode, including
+// the ones the session user isn't permitted to run, for tools like docs
+// generators or completion scripts of other shells. Commands registered
+// with RegisterLazy aren't loaded.
+func (c *Console) Catalog() []CatalogCmd {
+	var cat []CatalogCmd
+	for _, cmd := range c.commands() {
+		if cmd.Name != "" {
+			cat = append(cat, c.catalogCmd(cmd))
+		}
+	}
+	if c.exitCmd != nil {
+		e := c.catalogCmd(c.exitCmd)
+		e.Description, e.Builtin = c.msg(MsgExitConsole), true
+		cat = append(cat, e)
+	}
+	return cat
+}
+
+func (c *Console) catalogCmd(cmd *Cmd) CatalogCmd {
+	if cmd.lazy != nil && cmd.lazy.cmd != nil {
+		cmd = cmd.lazy.cmd
+	}
+	e := CatalogCmd{
+		Name:           cmd.Name,
+		Aliases:        cmd.Aliases,
+		Description:    c.description(cmd),
+		Group:          cmd.Group,
+		LongHelp:       cmd.LongHelp,
+		Examples:       cmd.Examples,
+		SeeAlso:        cmd.SeeAlso,
+		Danger:         cmd.Danger.String(),
+		Restricted:     cmd.Permission != nil,
+		Permitted:      cmd.permitted(c),
+		Builtin:        cmd.builtin,
+		SupportsDryRun: cmd.SupportsDryRun,
+		Lazy:           cmd.lazy != nil,
+	}
+	for _, sub := range cmd.subs {
+		e.Commands = append(e.Commands, c.catalogCmd(sub))
+	}
+	if b := cmd.binding; b != nil {
+		for _, a := range b.args {
+			e.Args = append(e.Args, CatalogArg{Name: a.name, Description: a.help, Values: a.enum, Required: a.required, Variadic: a.typ.Kind() == reflect.Slice})
+		}
+		for _, f := range b.flags {
+			e.Flags = append(e.Flags, CatalogFlag{Name: f.name, Short: f.short, Type: typeName(f.typ), Description: f.help, Default: f.def, Values: f.enum, Required: f.required})
+		}
+		return e
+	}
+	for _, a := range cmd.Args {
+		e.Args = append(e.Args, CatalogArg{Name: a.Name, Description: a.Description, Values: a.Values})
+	}
+	return e
+}
+
+// permittedCatalog drops the commands the session user isn't permitted to run,
+// which the help doesn't show either.
+func permittedCatalog(cat []CatalogCmd) []CatalogCmd {
+	var s []CatalogCmd
+	for _, cmd := range cat {
+		if cmd.Permitted {
+			cmd.Commands = permittedCatalog(cmd.Commands)
+			s = append(s, cmd)
+		}
+	}
+	return s
+}
+
+// renderCatalog lists the names and descriptions of the commands, with
+// the commands of namespaces indented.
+func renderCatalog(w io.Writer, data any) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	var list func(cmds []CatalogCmd, indent string)
+	list = func(cmds []CatalogCmd, indent string) {
+		for _, cmd := range cmds {
+			name := cmd.Name
+			if len(cmd.Aliases) > 0 {
+				name += " (" + strings.Join(cmd.Aliases, ", ") + ")"
+			}
+			fmt.Fprintf(tw, "%s%s\t%s\n", indent, name, cmd.Description)
+			list(cmd.Commands, indent+"  ")
+		}
+	}
+	list(data.([]CatalogCmd), "")
+	return tw.Flush()
+}
+
+var commandsCmd = &Cmd{
+	Name:        "commands",
+	Description: "List the commands, --json for all details",
+	descID:      MsgCommandsDescription,
+	builtin:     true,
+	Args:        []*Arg{{Name: "format", Values: []string{"--json"}}},
+	ResultHandler: func(ctx context.Context, c *Console, args []string) (*Result, error) {
+		res := NewResult(permittedCatalog(c.Catalog())).With(FormatText, renderCatalog).With(FormatTable, renderCatalog)
+		switch {
+		case len(args) == 1 && args[0] == "--json":
+			return res.With(c.OutputFormat(ctx), renderJSON), nil
+		case len(args) > 0:
+			return nil, errors.New(c.msg(MsgUsageError, "commands [--json]"))
+		}
+		return res, nil
+	},
+}
@@ -0,0 +1,48 @@
+package console
+
+import "errors"
+
+var errNotChild = errors.New("console is not a child of this console")
+
+// NewChild creates a console sharing the input, output and identity of c,
+// like a SQL shell entered from the db command. Apart from that the child
+// is independent of c and has its own commands, prompt and completion.
+// It's closed together with c.
+func (c *Console) NewChild(opts ...Opts) (*Console, error) {
+	base := []Opts{
+		WithLineReader(c.reader),
+		WithOutput(c.out),
+		WithContext(c.ctx),
+		WithHistoryFile(""),
+		WithIdentity(c.identity),
+		WithLogger(c.logger),
+		WithTheme(c.theme),
+		WithEnvPrefix(""),
+		func(child *Console) { child.workDir, child.format = c.workDir, c.format },
+	}
+	child, err := New(append(base, opts...)...)
+	// the child took over the completion of the shared reader
+	c.setCompleter()
+	if err != nil {
+		return nil, err
+	}
+	child.parent = c
+	child.isOsPipe = c.isOsPipe
+	child.recorder = c.recorder
+	return child, nil
+}
+
+// Push hands the input over to child until it exits, usually called
+// from a command handler. The child is closed by its exit command, so
+// a new child is needed to enter it again.
+func (c *Console) Push(child *Console) error {
+	if child.parent != c {
+		return errNotChild
+	}
+	child.setCompleter()
+	defer c.setCompleter()
+	if !child.isOsPipe && child.welcomeMsg != "" {
+		child.printWelcomeMsg()
+	}
+	return child.read()
+}
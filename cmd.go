@@ -1,52 +1,197 @@
 package console
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/jon4hz/console/parse"
 	"github.com/muesli/termenv"
 )
 
 var (
-	ErrCmdNoHandler = errors.New("command has no handler")
+	ErrCmdNoHandler     = errors.New("command has no handler")
+	ErrCmdNoConsole     = errors.New("command isn't registered with a console")
+	ErrPermissionDenied = errors.New("permission denied")
 )
 var defaultCmds = []*Cmd{
 	helpCmd,
 	clearCmd,
+	undoCmd,
+	statsCmd,
+	calcCmd,
+	formatCmd,
+	commandsCmd,
 }
 
 type Cmd struct {
-	Name                 string
-	Aliases              []string
-	Description          string
-	IgnorePipe           bool
+	Name        string
+	Aliases     []string
+	Description string
+	Args        []*Arg
+	IgnorePipe  bool
+	// ParsesOutputFlag passes the --output flag to the handler instead of
+	// setting the output format of the command, see Console.OutputFormat.
+	// It's implied for commands of Bind with a flag named output.
+	ParsesOutputFlag     bool
 	Matcher              func(cmd string) bool
 	IgnoreDefaultMatcher bool
 	Handler              func(c *Console, args []string) error
-	Console              *Console
+	// ContextHandler is used instead of Handler if set.
+	// The context is canceled once the console is closed.
+	ContextHandler func(ctx context.Context, c *Console, args []string) error
+	// ResultHandler is used instead of the other handlers if set.
+	// The returned Result is rendered by the console, see Result.
+	ResultHandler func(ctx context.Context, c *Console, args []string) (*Result, error)
+	// Permission reports whether the command may be seen and run.
+	// If nil, the command is always permitted.
+	Permission func(c *Console) bool
+	// Cooldown is the minimum time between two executions.
+	Cooldown time.Duration
+	// RateLimit limits the number of executions within a time window.
+	RateLimit *RateLimit
+	// Danger requires the user to confirm the command before it's run,
+	// depending on the confirmation policy of the console.
+	Danger DangerLevel
+	// SupportsDryRun marks commands whose handler checks Console.DryRun.
+	// Other dangerous commands can't be run during a dry-run.
+	SupportsDryRun bool
+	// Timeout is the maximum execution time. The context passed to the
+	// ContextHandler is canceled once it's exceeded.
+	Timeout time.Duration
+	// Concurrency describes how the command is executed while other commands are running.
+	Concurrency ConcurrencyPolicy
+	// Completer returns the completion candidates for the last of args,
+	// which is the word under the cursor. If nil, Args are used.
+	Completer func(c *Console, args []string) []string
+	// Group is the section of the help listing the command, see WithHelpOrder.
+	Group string
+	// LongHelp is the description shown on the help page of the command,
+	// see Console.RenderManPage. It may span several lines.
+	LongHelp string
+	// Examples are command lines shown on the help page, optionally
+	// followed by a comment like "deploy web --env prod  # deploy to production".
+	Examples []string
+	// SeeAlso names related commands.
+	SeeAlso []string
+	Console *Console
+
+	// bindErr is set by Bind if the parameters can't be bound.
+	bindErr error
+	// subs are the commands of a namespace.
+	subs []*Cmd
+	// descID is the message of the description of builtins.
+	descID MessageID
+	// builtin commands are replaced by registered commands with the same name.
+	builtin bool
+	// interactive commands prompt the user, e.g. for a password, so they can't be scheduled.
+	interactive bool
+	// lazy is set for placeholders of commands loaded on first use.
+	lazy *lazyCmd
+	// binding is set by Bind and describes the flags and arguments.
+	binding *binding
+}
+
+// Arg describes a positional argument of a command.
+// If Values is set, the argument only accepts a finite set of values
+// which are offered as completion candidates.
+type Arg struct {
+	Name        string
+	Description string
+	Values      []string
+}
+
+func (c *Cmd) permitted(con *Console) bool {
+	return c.Permission == nil || c.Permission(con)
+}
+
+// parsesOutputFlag reports whether --output is an argument of the command
+// rather than the output format.
+func (c *Cmd) parsesOutputFlag() bool {
+	if c.ParsesOutputFlag {
+		return true
+	}
+	if c.binding != nil {
+		for _, f := range c.binding.flags {
+			if f.name == "output" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *Cmd) names() []string {
+	return append([]string{c.Name}, c.Aliases...)
+}
+
+// completeArg returns the permitted values of the argument at position i
+// starting with prefix.
+func (c *Cmd) completeArg(i int, prefix string) (s []string) {
+	if i >= len(c.Args) {
+		return nil
+	}
+	for _, v := range c.Args[i].Values {
+		if strings.HasPrefix(v, prefix) {
+			s = append(s, v)
+		}
+	}
+	return
 }
 
-func (c *Cmd) defaultMatcher(cmd string) bool {
-	cmd, _ = splitCmdArgs(cmd)
-	if cmd == c.Name {
+// hasName reports whether name is the name or an alias of c.
+func (c *Cmd) hasName(name string) bool {
+	if name == c.Name {
 		return true
 	}
 	for _, alias := range c.Aliases {
-		if cmd == alias {
+		if name == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// collides reports whether c and cmd share a name or alias.
+func (c *Cmd) collides(cmd *Cmd) bool {
+	if c.hasName(cmd.Name) {
+		return true
+	}
+	for _, a := range cmd.Aliases {
+		if c.hasName(a) {
 			return true
 		}
 	}
 	return false
 }
 
+// firstWord returns the command name of the input like parse.Parse.
+// If the input can't be parsed, it's the word before the first space or tab.
+func firstWord(input string) string {
+	if inv, err := parse.Parse(input); err == nil {
+		return inv.Name
+	}
+	input = strings.TrimLeft(input, " \t")
+	if i := strings.IndexAny(input, " \t"); i >= 0 {
+		return input[:i]
+	}
+	return input
+}
+
 func splitCmdArgs(cmd string) (string, []string) {
 	args := strings.Split(cmd, " ")
 	return args[0], args[1:]
 }
 
 func (c *Cmd) Match(cmd string) bool {
-	if c.defaultMatcher(cmd) && !c.IgnoreDefaultMatcher {
+	return c.match(cmd, firstWord(cmd))
+}
+
+// match is Match with the first word of cmd already split off.
+func (c *Cmd) match(cmd, name string) bool {
+	if !c.IgnoreDefaultMatcher && c.hasName(name) {
 		return true
 	}
 	if c.Matcher != nil {
@@ -55,13 +200,40 @@ func (c *Cmd) Match(cmd string) bool {
 	return false
 }
 
+// Handle runs the handler with the arguments of cmd. Commands registered with
+// an Engine belong to many sessions and fail with ErrCmdNoConsole,
+// use Console.Run instead.
 func (c *Cmd) Handle(cmd string) error {
-	if c.Console.isOsPipe && c.IgnorePipe {
+	if c.Console == nil {
+		return ErrCmdNoConsole
+	}
+	inv, err := parse.Parse(cmd)
+	if err != nil {
+		return err
+	}
+	ctx := c.Console.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return c.handle(ctx, c.Console, inv.Args)
+}
+
+func (c *Cmd) handle(ctx context.Context, con *Console, args []string) error {
+	if con.isOsPipe && c.IgnorePipe {
 		return nil
 	}
+	if c.ResultHandler != nil {
+		res, err := c.ResultHandler(ctx, con, args)
+		if err != nil || res == nil {
+			return err
+		}
+		return con.Render(ctx, res)
+	}
+	if c.ContextHandler != nil {
+		return c.ContextHandler(ctx, con, args)
+	}
 	if c.Handler != nil {
-		_, args := splitCmdArgs(cmd)
-		return c.Handler(c.Console, args)
+		return c.Handler(con, args)
 	}
 	return ErrCmdNoHandler
 }
@@ -69,29 +241,38 @@ func (c *Cmd) Handle(cmd string) error {
 var helpCmd = &Cmd{
 	Name:        "help",
 	Description: "Show the help",
-	Handler: func(c *Console, args []string) error {
-		fmt.Println(helpView(c))
+	descID:      MsgHelpDescription,
+	builtin:     true,
+	Aliases:     []string{"man"},
+	Args:        []*Arg{{Name: "command", Description: "Command to show the help page of, or -k and a keyword to search for"}},
+	Completer:   completeHelp,
+	ContextHandler: func(ctx context.Context, c *Console, args []string) error {
+		w := c.Writer(ctx)
+		if len(args) > 0 && args[0] == "-k" {
+			if len(args) == 1 {
+				return errors.New(c.msg(MsgUsageError, "help -k <keyword>"))
+			}
+			fmt.Fprintln(w, c.Apropos(strings.Join(args[1:], " ")))
+			return nil
+		}
+		if len(args) > 0 {
+			page, err := c.RenderManPage(args...)
+			if err != nil {
+				return err
+			}
+			return c.page(w, page)
+		}
+		fmt.Fprintln(w, c.RenderHelp())
 		return nil
 	},
 }
 
-func helpView(c *Console) string {
-	s := "Available commands:"
-	for _, cmd := range c.cmds {
-		if cmd.Name != "" && cmd.Description != "" {
-			s += fmt.Sprintf("\n  %s - %s", cmd.Name, cmd.Description)
-		}
-	}
-	if c.exitCmd != nil {
-		s += fmt.Sprintf("\n  %s - Exit the console", c.exitCmd.Name)
-	}
-	return s
-}
-
 var quitCmd = &Cmd{
 	Name:        "quit",
 	Aliases:     []string{"exit"},
 	Description: "Quit the console",
+	descID:      MsgQuitDescription,
+	builtin:     true,
 	IgnorePipe:  true,
 	Handler: func(c *Console, args []string) error {
 		c.Close()
@@ -102,9 +283,16 @@ var quitCmd = &Cmd{
 var clearCmd = &Cmd{
 	Name:        "clear",
 	Description: "Clear the screen",
+	descID:      MsgClearDescription,
+	builtin:     true,
 	IgnorePipe:  true,
 	Handler: func(c *Console, args []string) error {
-		termenv.ClearScreen()
+		c.clearScreen()
 		return nil
 	},
 }
+
+func (c *Console) clearScreenANSI() {
+	c.Printf(termenv.CSI+termenv.EraseDisplaySeq, 2)
+	c.Printf(termenv.CSI+termenv.CursorPositionSeq, 1, 1)
+}
@@ -0,0 +1,176 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+var ErrCmdBusy = errors.New("another command is running")
+
+// ConcurrencyPolicy describes how a command is executed while other commands are running.
+type ConcurrencyPolicy int
+
+const (
+	// ConcurrencyDefault uses the policy of the console.
+	ConcurrencyDefault ConcurrencyPolicy = iota
+	// ConcurrencySerial waits until the running commands are done.
+	ConcurrencySerial
+	// ConcurrencyReject fails with ErrCmdBusy while another command is running.
+	ConcurrencyReject
+	// ConcurrencyConcurrent runs the command in the background and returns to the prompt immediately.
+	// Its output must be written to Console.Writer, which tags every line with the command,
+	// instead of Console.Println or Console.Stdout.
+	ConcurrencyConcurrent
+)
+
+// WithConcurrency sets the policy of commands using ConcurrencyDefault.
+// Without this option, commands are executed serially.
+func WithConcurrency(p ConcurrencyPolicy) Opts {
+	return func(c *Console) {
+		c.concurrency = p
+	}
+}
+
+func (c *Console) concurrencyPolicy(cmd *Cmd) ConcurrencyPolicy {
+	if cmd.Concurrency != ConcurrencyDefault {
+		return cmd.Concurrency
+	}
+	if c.concurrency != ConcurrencyDefault {
+		return c.concurrency
+	}
+	return ConcurrencySerial
+}
+
+type execKey struct{}
+
+// execution is stored in the context of a running command.
+type execution struct {
+	id  uint64
+	cmd *Cmd
+	out io.Writer
+}
+
+func executionFrom(ctx context.Context) *execution {
+	e, _ := ctx.Value(execKey{}).(*execution)
+	return e
+}
+
+// Writer returns the writer for the output of the command running with ctx.
+// For concurrent commands, every line is tagged with the name of the command.
+func (c *Console) Writer(ctx context.Context) io.Writer {
+	if e := executionFrom(ctx); e != nil && e.out != nil {
+		return e.out
+	}
+	return c.out
+}
+
+// schedule runs fn according to the concurrency policy of cmd.
+// Commands started by a running command, e.g. by sudo, are run directly.
+func (c *Console) schedule(ctx context.Context, cmd *Cmd, fn func(ctx context.Context) error) error {
+	if executionFrom(ctx) != nil {
+		return fn(ctx)
+	}
+	e := &execution{id: atomic.AddUint64(&c.execID, 1), cmd: cmd}
+	ctx = context.WithValue(ctx, execKey{}, e)
+
+	switch c.concurrencyPolicy(cmd) {
+	case ConcurrencyReject:
+		if !c.execMu.TryLock() {
+			return ErrCmdBusy
+		}
+		defer c.execMu.Unlock()
+		return fn(ctx)
+	case ConcurrencyConcurrent:
+		tag := fmt.Sprintf("[%s#%d] ", cmd.Name, e.id)
+		out := &promptWriter{c: c}
+		tw := &tagWriter{w: out, tag: tag}
+		e.out = tw
+		go func() {
+			c.execMu.RLock()
+			defer c.execMu.RUnlock()
+			err := fn(ctx)
+			tw.Flush()
+			if err != nil {
+				fmt.Fprintln(out, c.theme.Error.Render(c.msg(MsgCmdFailed, tag, err)))
+			} else {
+				fmt.Fprintln(out, c.msg(MsgCmdDone, tag))
+			}
+		}()
+		return nil
+	default:
+		c.execMu.Lock()
+		defer c.execMu.Unlock()
+		return fn(ctx)
+	}
+}
+
+// promptWriter writes the output of concurrent commands and jobs to the console. Output written while
+// the prompt of the local terminal is shown clears the prompt, which is redrawn
+// afterwards. TerminalReaders redraw the prompt themselves.
+type promptWriter struct {
+	c *Console
+}
+
+func (w *promptWriter) Write(p []byte) (int, error) {
+	c := w.c
+	c.promptMu.Lock()
+	defer c.promptMu.Unlock()
+	if _, ok := c.reader.(*linerReader); !ok || c.shownPrompt == "" {
+		return c.out.Write(p)
+	}
+	if _, err := io.WriteString(c.out, "\r\x1b[K"); err != nil {
+		return 0, err
+	}
+	n, err := c.out.Write(p)
+	if err != nil {
+		return n, err
+	}
+	_, err = io.WriteString(c.out, c.shownPrompt)
+	return n, err
+}
+
+// setShownPrompt sets the prompt which is displayed while waiting for input.
+func (c *Console) setShownPrompt(prompt string) {
+	c.promptMu.Lock()
+	defer c.promptMu.Unlock()
+	c.shownPrompt = prompt
+}
+
+// tagWriter prefixes every line with a tag.
+type tagWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	tag string
+	buf []byte
+}
+
+func (t *tagWriter) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	for {
+		i := bytes.IndexByte(t.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		if _, err := fmt.Fprintf(t.w, "%s%s", t.tag, t.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		t.buf = t.buf[i+1:]
+	}
+}
+
+// Flush writes an incomplete last line.
+func (t *tagWriter) Flush() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.buf) > 0 {
+		fmt.Fprintf(t.w, "%s%s\n", t.tag, t.buf)
+		t.buf = nil
+	}
+}
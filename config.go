@@ -0,0 +1,229 @@
+package console
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jon4hz/console/parse"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Config customizes a console from a YAML or TOML file.
+//
+//	prompt: "app> "
+//	locale: de
+//	history:
+//	  file: ~/.app_history
+//	theme:
+//	  error: "#FF0000"
+//	keybindings:
+//	  ctrl_c: ignore
+//	  tab: list
+//	aliases:
+//	  ll: list --long
+//	disabled_builtins: [stats, undo]
+//	help_order: group
+//	output: json
+type Config struct {
+	Prompt     string `yaml:"prompt" toml:"prompt"`
+	WelcomeMsg string `yaml:"welcome_msg" toml:"welcome_msg"`
+	Locale     string `yaml:"locale" toml:"locale"`
+	History    struct {
+		// File is the history file, an empty string disables the history.
+		File *string `yaml:"file" toml:"file"`
+	} `yaml:"history" toml:"history"`
+	Theme struct {
+		Error string `yaml:"error" toml:"error"`
+		Stack string `yaml:"stack" toml:"stack"`
+	} `yaml:"theme" toml:"theme"`
+	Keybindings struct {
+		// CtrlC is either "abort" or "ignore".
+		CtrlC string `yaml:"ctrl_c" toml:"ctrl_c"`
+		// Tab is either "cycle" or "list".
+		Tab string `yaml:"tab" toml:"tab"`
+	} `yaml:"keybindings" toml:"keybindings"`
+	Aliases          map[string]string `yaml:"aliases" toml:"aliases"`
+	DisabledBuiltins []string          `yaml:"disabled_builtins" toml:"disabled_builtins"`
+	// HelpOrder is "registration", "alphabetical" or "group".
+	HelpOrder string `yaml:"help_order" toml:"help_order"`
+	// Output is the output format, see Formats.
+	Output string `yaml:"output" toml:"output"`
+}
+
+// LoadConfig reads a config file. The format is detected by the file extension.
+func LoadConfig(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &cfg)
+	case ".toml":
+		err = toml.Unmarshal(b, &cfg)
+	default:
+		return nil, fmt.Errorf("unsupported config format %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Opts returns the options configured by the config. Like other options,
+// they are overridden by the environment, see WithEnvPrefix.
+func (cfg *Config) Opts() ([]Opts, error) {
+	var opts []Opts
+	if cfg.Prompt != "" {
+		opts = append(opts, WithPrompt(cfg.Prompt))
+	}
+	if cfg.WelcomeMsg != "" {
+		opts = append(opts, WithWelcomeMsg(cfg.WelcomeMsg))
+	}
+	if cfg.Locale != "" {
+		opts = append(opts, WithLocale(cfg.Locale))
+	}
+	if f := cfg.History.File; f != nil {
+		opts = append(opts, WithHistoryFile(expandHome(*f)))
+	}
+	if cfg.Theme.Error != "" || cfg.Theme.Stack != "" {
+		errColor, stackColor := cfg.Theme.Error, cfg.Theme.Stack
+		opts = append(opts, func(c *Console) {
+			if errColor != "" {
+				c.theme.Error = lipgloss.NewStyle().Foreground(lipgloss.Color(errColor))
+			}
+			if stackColor != "" {
+				c.theme.Stack = lipgloss.NewStyle().Foreground(lipgloss.Color(stackColor))
+			}
+		})
+	}
+	switch cfg.Keybindings.CtrlC {
+	case "":
+	case "abort":
+		opts = append(opts, WithHandleCtrlC(true))
+	case "ignore":
+		opts = append(opts, WithHandleCtrlC(false))
+	default:
+		return nil, fmt.Errorf("invalid ctrl_c keybinding %q", cfg.Keybindings.CtrlC)
+	}
+	switch cfg.Keybindings.Tab {
+	case "":
+	case "cycle":
+		opts = append(opts, WithValuePicker(true))
+	case "list":
+		opts = append(opts, WithValuePicker(false))
+	default:
+		return nil, fmt.Errorf("invalid tab keybinding %q", cfg.Keybindings.Tab)
+	}
+	if len(cfg.Aliases) > 0 {
+		opts = append(opts, WithAliases(cfg.Aliases))
+	}
+	if len(cfg.DisabledBuiltins) > 0 {
+		opts = append(opts, WithDisabledBuiltins(cfg.DisabledBuiltins...))
+	}
+	switch cfg.HelpOrder {
+	case "":
+	case "registration":
+		opts = append(opts, WithHelpOrder(HelpOrderRegistration))
+	case "alphabetical":
+		opts = append(opts, WithHelpOrder(HelpOrderAlphabetical))
+	case "group":
+		opts = append(opts, WithHelpOrder(HelpOrderGroup))
+	default:
+		return nil, fmt.Errorf("invalid help order %q", cfg.HelpOrder)
+	}
+	if cfg.Output != "" {
+		if err := checkFormat(Format(cfg.Output)); err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithOutputFormat(Format(cfg.Output)))
+	}
+	return opts, nil
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// WithConfigFile applies the settings of a config file.
+// Options following it override the config.
+func WithConfigFile(path string) Opts {
+	return func(c *Console) {
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			c.optErr = err
+			return
+		}
+		opts, err := cfg.Opts()
+		if err != nil {
+			c.optErr = fmt.Errorf("error in config %s: %w", path, err)
+			return
+		}
+		for _, opt := range opts {
+			opt(c)
+		}
+	}
+}
+
+// NewFromConfig creates a console configured by a config file.
+// The config is applied after the given options, so end users can override them.
+func NewFromConfig(path string, opts ...Opts) (*Console, error) {
+	return New(append(opts, WithConfigFile(path))...)
+}
+
+// WithAliases expands the first word of the input if it's an alias.
+// E.g. the alias "ll" for "list --long" expands "ll /tmp" to "list --long /tmp".
+func WithAliases(aliases map[string]string) Opts {
+	return func(c *Console) {
+		if c.aliases == nil {
+			c.aliases = make(map[string]string, len(aliases))
+		}
+		for k, v := range aliases {
+			c.aliases[k] = v
+		}
+	}
+}
+
+// WithoutDefaultCmds drops the default builtins like help and clear.
+// The exit command is kept, see WithExitCmd.
+func WithoutDefaultCmds() Opts {
+	return func(c *Console) {
+		c.withoutDefaultCmds = true
+	}
+}
+
+// WithDisabledBuiltins removes the given builtin commands.
+func WithDisabledBuiltins(names ...string) Opts {
+	return func(c *Console) {
+		if c.disabledBuiltins == nil {
+			c.disabledBuiltins = make(map[string]bool, len(names))
+		}
+		for _, n := range names {
+			c.disabledBuiltins[n] = true
+		}
+	}
+}
+
+func (c *Console) expandAlias(input string) string {
+	return parse.Expand(input, c.aliases)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
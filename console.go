@@ -5,11 +5,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
 
-	"github.com/peterh/liner"
+	"github.com/jon4hz/console/parse"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var defaultHistoryFile = filepath.Join(os.TempDir(), ".console_history")
@@ -48,7 +55,24 @@ func WithWelcomeMsg(msg string) Opts {
 
 func WithHandleCtrlC(handle bool) Opts {
 	return func(c *Console) {
-		c.liner.SetCtrlCAborts(handle)
+		c.ctrlCAborts = handle
+	}
+}
+
+// WithValuePicker controls how argument values are offered on tab.
+// If enabled (the default), pressing tab cycles through the permitted values
+// in place. Otherwise, the candidates are printed as a plain list.
+func WithValuePicker(enabled bool) Opts {
+	return func(c *Console) {
+		c.valuePicker = enabled
+	}
+}
+
+// WithLogger sets the logger for internal events and errors.
+// By default, only warnings and errors are logged to stderr.
+func WithLogger(l *slog.Logger) Opts {
+	return func(c *Console) {
+		c.logger = l
 	}
 }
 
@@ -64,84 +88,274 @@ type Console struct {
 	cancel    context.CancelFunc
 	isOsPipe  bool
 
-	liner       *liner.State
+	reader      LineReader
+	ctrlCAborts bool
+	valuePicker bool
 	historyFile string
 	welcomeMsg  string
+	welcomeTmpl *template.Template
+	version     *Version
 	prompt      string
 	promptC     <-chan string
 
-	cmds    []*Cmd
-	exitCmd *Cmd
+	engine    *Engine
+	cmds      []*Cmd
+	exitCmd   *Cmd
+	modes     map[string]*Mode
+	modeStack []*Mode
+	// parent is set for children, which share its reader and recorder.
+	parent *Console
+
+	locale   Messages
+	messages Messages
+
+	hooksMu sync.RWMutex
+	hooks   map[EventType][]*hook
+
+	undoMu    sync.Mutex
+	undoStack []undoEntry
+
+	storeMu sync.RWMutex
+	store   map[string]any
+
+	audit           AuditLogger
+	auth            AuthFunc
+	identity        Identity
+	elevation       *Elevation
+	elevationMu     sync.Mutex
+	elevatedUntil   time.Time
+	confirmPolicies map[DangerLevel]ConfirmPolicy
+
+	stats   *Stats
+	metrics []Metrics
+	tracer  trace.Tracer
+
+	logger         *slog.Logger
+	repanic        bool
+	defaultTimeout time.Duration
+
+	theme              Theme
+	helpOrder          HelpOrder
+	aliases            map[string]string
+	disabledBuiltins   map[string]bool
+	withoutDefaultCmds bool
+	optErr             error
+	envPrefix          string
+
+	concurrency ConcurrencyPolicy
+	execMu      sync.RWMutex
+	execID      uint64
+	// shownPrompt is the prompt displayed while reading input, see promptWriter.
+	promptMu    sync.Mutex
+	shownPrompt string
+
+	workDir       string
+	prevWorkDir   string
+	workDirCmds   bool
+	workDirPrompt bool
+	execCmd       bool
+	dryRunCmd     bool
+	dryRun        atomic.Bool
+	schedulerCmds bool
+	jobs          scheduler
+
+	out io.Writer
+	// format is guarded by storeMu as it can be changed by the format builtin.
+	format        Format
+	restoreTTY    func()
+	legacyConsole bool
+	noPager       bool
+	recorder      *recorder
+	recordingFile string
 }
 
+// New creates a standalone console.
+// Use an Engine to create many sessions sharing the same commands.
 func New(opts ...Opts) (*Console, error) {
+	return newConsole(NewEngine(), opts...)
+}
+
+func newConsole(e *Engine, opts ...Opts) (*Console, error) {
 	c := &Console{
+		engine:      e,
 		parentCtx:   context.Background(),
-		liner:       liner.NewLiner(),
 		historyFile: defaultHistoryFile,
 		exitCmd:     quitCmd,
+		ctrlCAborts: true,
+		valuePicker: true,
 		prompt:      "> ",
+		stats:       NewStats(),
+		out:         os.Stdout,
+		identity:    Identity{Name: currentUser()},
+		theme:       DefaultTheme(),
+		envPrefix:   defaultEnvPrefix,
+		logger:      slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.optErr != nil {
+		return nil, c.optErr
+	}
+	if err := c.applyEnv(); err != nil {
+		return nil, err
 	}
-	quitCmd.Console = c
-	c.liner.SetCtrlCAborts(true)
 
-	// check if stdin is a pipe
-	if isPipe, err := fileIsPipe(os.Stdin); err != nil {
-		return nil, fmt.Errorf("error checking if stdin is a pipe: %s", err)
-	} else if isPipe {
-		c.isOsPipe = true
+	if c.reader == nil {
+		c.isOsPipe = fileIsPipe(os.Stdin)
+		c.reader = newLinerReader(c.ctrlCAborts)
+		c.setupTTY()
+	}
+	if vp, ok := c.reader.(valuePicker); ok {
+		vp.setValuePicker(c.valuePicker)
 	}
 
-	for _, opt := range opts {
-		opt(c)
+	if c.recordingFile != "" {
+		r, err := newRecorder(c.recordingFile, c.out)
+		if err != nil {
+			return nil, fmt.Errorf("error creating recording: %w", err)
+		}
+		c.recorder = r
+		c.out = r
 	}
 
 	ctx, cancel := context.WithCancel(c.parentCtx)
 	c.ctx = ctx
 	c.cancel = cancel
-	if err := c.RegisterCommands(defaultCmds...); err != nil {
-		return nil, err
+	// builtins are shared between all consoles and don't belong to one of them
+	for _, cmd := range defaultCmds {
+		if !c.withoutDefaultCmds && !c.disabledBuiltins[cmd.Name] {
+			c.cmds = append(c.cmds, cmd)
+		}
+	}
+	if c.elevation != nil {
+		if err := c.registerCommands(false, elevationCmds...); err != nil {
+			return nil, err
+		}
+	}
+	if c.workDirCmds {
+		if err := c.registerCommands(false, cdCmd, pwdCmd); err != nil {
+			return nil, err
+		}
+	}
+	if c.execCmd {
+		if err := c.registerCommands(false, execCmd); err != nil {
+			return nil, err
+		}
 	}
+	if c.dryRunCmd {
+		if err := c.registerCommands(false, dryRunCmd); err != nil {
+			return nil, err
+		}
+	}
+	if c.schedulerCmds {
+		if err := c.registerCommands(false, everyCmd, atCmd, scheduleCmd); err != nil {
+			return nil, err
+		}
+	}
+	if c.version != nil {
+		if err := c.registerCommands(false, versionCmd); err != nil {
+			return nil, err
+		}
+	}
+	c.welcomeTmpl = c.welcomeTemplate()
 	c.setCompleter()
 
 	return c, nil
 }
 
-func fileIsPipe(in *os.File) (bool, error) {
-	if fi, _ := in.Stat(); (fi.Mode() & os.ModeNamedPipe) != 0 {
-		return true, nil
-	}
-	return false, nil
+// RegisterCommands registers commands only available in this console.
+// If a name or alias is invalid or already taken, nothing is registered
+// and a *RegistrationError listing all of them is returned.
+func (c *Console) RegisterCommands(cmds ...*Cmd) error {
+	return c.registerCommands(true, cmds...)
 }
 
-func (c *Console) RegisterCommands(cmds ...*Cmd) error {
+func (c *Console) registerCommands(own bool, cmds ...*Cmd) error {
+	r := registration{existing: c.registered()}
+	for _, cmd := range cmds {
+		if !cmd.builtin {
+			r.check(cmd)
+		}
+	}
+	if err := r.err(); err != nil {
+		return err
+	}
 	for _, cmd := range cmds {
-		if c.checkCmdRegistered(cmd) {
-			return errors.New("command matches an existing command")
+		if own {
+			cmd.Console = c
+		}
+		if !cmd.builtin && c.exitCmd != nil && c.exitCmd.builtin && cmdRegistered([]*Cmd{c.exitCmd}, cmd) {
+			c.exitCmd = cmd
+			continue
 		}
-		cmd.Console = c
 		c.cmds = append(c.cmds, cmd)
 	}
 	return nil
 }
 
-func (c *Console) checkCmdRegistered(cmd *Cmd) bool {
+// UnregisterCommands removes commands registered with RegisterCommands.
+// Commands not registered in this console are ignored.
+func (c *Console) UnregisterCommands(cmds ...*Cmd) {
+	var kept []*Cmd
 	for _, n := range c.cmds {
-		for _, v := range append(n.Aliases, n.Name) {
-			if v == cmd.Name {
-				return true
-			}
-			for _, a := range cmd.Aliases {
-				if a == v {
-					return true
-				}
-			}
+		if !slices.Contains(cmds, n) {
+			kept = append(kept, n)
+		}
+	}
+	c.cmds = kept
+}
+
+// commands returns the commands of the current mode, the commands of the console
+// and the commands of the engine in this order. Builtins replaced by a
+// registered command are left out.
+func (c *Console) commands() []*Cmd {
+	registered := c.registered()
+	cmds := c.modeCommands()
+	for _, cmd := range c.cmds {
+		if !cmd.builtin || !cmdRegistered(registered, cmd) {
+			cmds = append(cmds, cmd)
+		}
+	}
+	return append(cmds, c.engine.commands()...)
+}
+
+// registered returns the registered commands of the console and the engine without builtins.
+func (c *Console) registered() []*Cmd {
+	var cmds []*Cmd
+	for _, cmd := range c.cmds {
+		if !cmd.builtin {
+			cmds = append(cmds, cmd)
+		}
+	}
+	return append(cmds, c.engine.commands()...)
+}
+
+func cmdRegistered(cmds []*Cmd, cmd *Cmd) bool {
+	for _, n := range cmds {
+		if n.collides(cmd) {
+			return true
 		}
 	}
 	return false
 }
 
+// shadowed reports whether the builtin cmd is replaced by a registered command.
+func (c *Console) shadowed(cmd *Cmd) bool {
+	for _, n := range c.cmds {
+		if !n.builtin && n.collides(cmd) {
+			return true
+		}
+	}
+	return cmdRegistered(c.engine.commands(), cmd)
+}
+
 func (c *Console) Start() error {
+	c.logger.Info("starting console", "pipe", c.isOsPipe)
+	if err := c.authenticate(); err != nil {
+		return err
+	}
+	c.emit(Event{Type: EventStart})
 	if !c.isOsPipe {
 		c.printWelcomeMsg()
 	}
@@ -167,9 +381,24 @@ func (c *Console) updatePrompt() {
 }
 
 func (c *Console) Close() error {
+	c.logger.Info("closing console")
+	if c.ctx.Err() == nil {
+		c.emit(Event{Type: EventExit})
+	}
 	c.cancel()
 	c.writeHistory()
-	c.liner.Close()
+	if c.parent != nil {
+		return nil
+	}
+	c.reader.Close()
+	if c.restoreTTY != nil {
+		c.restoreTTY()
+	}
+	if c.recorder != nil {
+		if err := c.recorder.Close(); err != nil {
+			c.logger.Error("error closing recording", "err", err)
+		}
+	}
 	return nil
 }
 
@@ -178,27 +407,79 @@ func (c *Console) ExitCmd() (*Cmd, bool) {
 }
 
 func (c *Console) setCompleter() {
-	c.liner.SetCompleter(func(line string) (s []string) {
-		for _, n := range append(c.cmds, c.exitCmd) {
-			if n == nil {
-				continue
+	c.reader.SetCompleter(c.complete)
+}
+
+func (c *Console) complete(line string) (s []string) {
+	c.logger.Debug("completion requested", "line", line)
+	if strings.Contains(line, " ") {
+		return c.completeArgs(line)
+	}
+	prefix := strings.ToLower(line)
+	for _, n := range append(c.commands(), c.exitCmd) {
+		if n == nil || !n.permitted(c) {
+			continue
+		}
+		if strings.HasPrefix(n.Name, prefix) {
+			s = append(s, n.Name)
+			continue
+		}
+		for _, a := range n.Aliases {
+			if strings.HasPrefix(a, prefix) {
+				s = append(s, a)
 			}
-			if strings.HasPrefix(n.Name, strings.ToLower(line)) {
-				s = append(s, n.Name)
+		}
+	}
+	for _, a := range sortedKeys(c.aliases) {
+		if strings.HasPrefix(a, prefix) {
+			s = append(s, a)
+		}
+	}
+	return
+}
+
+// completeArgs completes the value of the argument under the cursor
+// if the command declares a finite value set or a completer for it.
+func (c *Console) completeArgs(line string) (s []string) {
+	name, args := splitCmdArgs(line)
+	for _, n := range c.commands() {
+		if !n.permitted(c) {
+			continue
+		}
+		for _, v := range n.names() {
+			if v != name {
 				continue
 			}
-			for _, a := range n.Aliases {
-				if strings.HasPrefix(a, strings.ToLower(line)) {
-					s = append(s, a)
-				}
+			head := line[:len(line)-len(args[len(args)-1])]
+			for _, val := range c.completeCmdArgs(n, args) {
+				s = append(s, head+val)
 			}
+			return
 		}
-		return
-	})
+	}
+	return
+}
+
+// completeCmdArgs returns the candidates for the last of args of cmd.
+func (c *Console) completeCmdArgs(cmd *Cmd, args []string) []string {
+	i := len(args) - 1
+	if cmd.subs != nil {
+		if i == 0 {
+			return cmd.completeSub(c, args[0])
+		}
+		if sub := cmd.sub(args[0]); sub != nil && sub.permitted(c) {
+			return c.completeCmdArgs(sub, args[1:])
+		}
+		return nil
+	}
+	if cmd.Completer != nil {
+		return cmd.Completer(c, args)
+	}
+	return cmd.completeArg(i, args[i])
 }
 
 func (c *Console) printWelcomeMsg() {
-	fmt.Println(c.welcomeMsg)
+	c.Println(c.renderWelcomeMsg())
 }
 
 func (c *Console) read() error {
@@ -206,24 +487,31 @@ func (c *Console) read() error {
 	go func() {
 		defer close(doneC)
 		for {
-			if in, err := c.liner.Prompt(c.prompt); err == nil {
+			prompt := c.currentPrompt()
+			c.emit(Event{Type: EventPrompt, Prompt: prompt})
+			c.setShownPrompt(prompt)
+			in, err := c.reader.Prompt(prompt)
+			c.setShownPrompt("")
+			if err == nil {
+				c.recordInput(prompt, in)
 				in = strings.TrimSpace(in)
 				if in == "" {
 					continue
 				}
 				c.appendHistory(in)
 				if exit, err := c.handleInput(in); err != nil {
-					fmt.Println(StyleError.Render(err.Error()))
+					c.Println(c.theme.Error.Render(err.Error()))
 				} else if exit { // prevent an unnecessary newline
 					break
 				}
-			} else if err == liner.ErrPromptAborted {
-				fmt.Println("Aborted")
+			} else if err == ErrPromptAborted {
+				c.Println(c.msg(MsgAborted))
 				break
 			} else if err == io.EOF {
 				break
 			} else {
-				fmt.Println(StyleError.Render(fmt.Sprintf("Error reading line: %s", err)))
+				c.logger.Error("error reading line", "err", err)
+				c.Println(c.theme.Error.Render(c.msg(MsgErrorReadingLine, err)))
 				break
 			}
 		}
@@ -246,11 +534,12 @@ func (c *Console) readHistory() {
 		if os.IsNotExist(err) {
 			return
 		}
-		fmt.Println(StyleError.Render(fmt.Sprintf("Error opening history file: %s", err)))
+		c.logger.Error("error opening history file", "file", c.historyFile, "err", err)
+		return
 	}
 	defer f.Close()
-	if _, err := c.liner.ReadHistory(f); err != nil {
-		fmt.Println(StyleError.Render(fmt.Sprintf("Error reading history file: %s", err)))
+	if _, err := c.reader.ReadHistory(f); err != nil {
+		c.logger.Error("error reading history file", "file", c.historyFile, "err", err)
 	}
 }
 
@@ -260,11 +549,12 @@ func (c *Console) writeHistory() {
 	}
 	f, err := os.Create(c.historyFile)
 	if err != nil {
-		fmt.Println(StyleError.Render(fmt.Sprintf("Error creating history file: %s", err)))
+		c.logger.Error("error creating history file", "file", c.historyFile, "err", err)
+		return
 	}
 	defer f.Close()
-	if _, err := c.liner.WriteHistory(f); err != nil {
-		fmt.Println(StyleError.Render(fmt.Sprintf("Error writing history file: %s", err)))
+	if _, err := c.reader.WriteHistory(f); err != nil {
+		c.logger.Error("error writing history file", "file", c.historyFile, "err", err)
 	}
 }
 
@@ -272,26 +562,170 @@ func (c *Console) appendHistory(in string) {
 	if c.historyFile == "" {
 		return
 	}
-	c.liner.AppendHistory(in)
+	c.reader.AppendHistory(in)
 }
 
 func (c *Console) handleInput(input string) (exit bool, err error) {
+	return c.dispatch(c.ctx, input)
+}
+
+func (c *Console) dispatch(ctx context.Context, input string) (exit bool, err error) {
+	input = c.expandAlias(input)
+	inv, err := parse.Parse(input)
+	if err != nil {
+		return false, err
+	}
+	args := inv.Args
+	if m := c.mode(); m != nil && m.ExitCmd.match(input, inv.Name) {
+		return false, c.execute(ctx, m.ExitCmd, input, args)
+	}
 	if e, ok := c.ExitCmd(); ok {
-		if e.Match(input) {
-			return true, e.Handle(input)
+		if e.match(input, inv.Name) {
+			c.logger.Debug("dispatching exit command", "command", e.Name)
+			return true, c.execute(ctx, e, input, args)
 		}
 	}
-	for _, cmd := range c.cmds {
-		if cmd.Match(input) {
-			if err := cmd.Handle(input); err != nil {
-				fmt.Println(StyleError.Render(fmt.Sprintf("error running command %s: %s\n", cmd.Name, err)))
-			}
-			return false, nil
+	if cmd, args := c.lookup(input, inv); cmd != nil {
+		c.logger.Debug("dispatching command", "command", cmd.Name)
+		if err := c.execute(ctx, cmd, input, args); err != nil {
+			c.logger.Error("error running command", "command", cmd.Name, "err", err)
+			c.Println(c.theme.Error.Render(c.msg(MsgErrorRunningCmd, cmd.Name, err) + "\n"))
 		}
+		return false, nil
 	}
+	c.logger.Debug("no command matched", "input", input)
 	return false, nil
 }
 
+// lookup returns the command matching input, parsed to inv, and its arguments.
+// Commands of a namespace are matched by the first argument.
+// It's on the path of every line, so unlike commands it doesn't build a list.
+func (c *Console) lookup(input string, inv parse.Invocation) (*Cmd, []string) {
+	name, args := inv.Name, inv.Args
+	match := func(cmds []*Cmd) *Cmd {
+		for _, cmd := range cmds {
+			if cmd.match(input, name) && (!cmd.builtin || !c.shadowed(cmd)) {
+				return cmd
+			}
+		}
+		return nil
+	}
+	cmd := match(c.modeCommands())
+	if cmd == nil {
+		cmd = match(c.cmds)
+	}
+	if cmd == nil {
+		cmd = match(c.engine.commands())
+	}
+	if cmd == nil {
+		return nil, nil
+	}
+	cmd = cmd.resolve()
+	if cmd.subs != nil && len(args) > 0 {
+		if sub := cmd.sub(args[0]); sub != nil {
+			return sub, args[1:]
+		}
+	}
+	return cmd, args
+}
+
+// ErrCmdNotFound is returned by Run if no command matches the input.
+var ErrCmdNotFound = errors.New("command not found")
+
+// Run runs a command line like it was entered at the prompt and returns
+// the error of the command instead of printing it. The exit command can't be run.
+func (c *Console) Run(ctx context.Context, line string) error {
+	line = c.expandAlias(strings.TrimSpace(line))
+	inv, err := parse.Parse(line)
+	if err != nil {
+		return err
+	}
+	cmd, args := c.lookup(line, inv)
+	if cmd == nil {
+		return fmt.Errorf("%w: %s", ErrCmdNotFound, line)
+	}
+	return c.execute(ctx, cmd, line, args)
+}
+
+func (c *Console) execute(ctx context.Context, cmd *Cmd, input string, args []string) error {
+	start := time.Now()
+	if !cmd.parsesOutputFlag() {
+		rest, f, err := outputFlag(args)
+		if err != nil {
+			return c.reject(input, cmd, args, start, err)
+		}
+		if f != "" {
+			ctx = context.WithValue(ctx, formatKey{}, f)
+		}
+		args = rest
+	}
+	if !cmd.permitted(c) {
+		return c.reject(input, cmd, args, start, ErrPermissionDenied)
+	}
+	if err := c.engine.allow(cmd, start, false); err != nil {
+		return c.reject(input, cmd, args, start, err)
+	}
+	if err := c.checkDryRun(cmd); err != nil {
+		return c.reject(input, cmd, args, start, err)
+	}
+	if !c.DryRun() {
+		if err := c.confirm(cmd, args); err != nil {
+			return c.reject(input, cmd, args, start, err)
+		}
+	}
+	// only confirmed commands count, checked again as other sessions may have run it
+	if err := c.engine.allow(cmd, time.Now(), true); err != nil {
+		return c.reject(input, cmd, args, start, err)
+	}
+	err := c.schedule(ctx, cmd, func(ctx context.Context) error {
+		c.emit(Event{Type: EventCommandStart, Command: cmd, Input: input, Args: args})
+		ctx, end := c.startSpan(ctx, cmd, args)
+		err := c.runHandler(ctx, cmd, args)
+		end(err)
+		c.observe(cmd, time.Since(start), err)
+		c.auditLog(input, cmd, start, err)
+		c.emit(Event{Type: EventCommandEnd, Command: cmd, Input: input, Args: args, Duration: time.Since(start), Err: err})
+		if err != nil {
+			c.emit(Event{Type: EventError, Command: cmd, Input: input, Args: args, Err: err})
+		}
+		return err
+	})
+	if err == ErrCmdBusy {
+		return c.reject(input, cmd, args, start, err)
+	}
+	return err
+}
+
+// reject audits and reports a command which wasn't run.
+func (c *Console) reject(input string, cmd *Cmd, args []string, start time.Time, err error) error {
+	c.auditLog(input, cmd, start, err)
+	c.emit(Event{Type: EventError, Command: cmd, Input: input, Args: args, Err: err})
+	return err
+}
+
 func (c *Console) Ctx() context.Context {
 	return c.ctx
 }
+
+// Stdout returns the writer for the output of the console.
+// Handlers should write to it instead of os.Stdout,
+// so their output is included in recordings and remote sessions.
+//
+// Output written to Stdout, Println and Printf isn't tagged with the command.
+// Handlers of concurrent commands or commands run by jobs should write to
+// Writer instead, as all builtins do.
+func (c *Console) Stdout() io.Writer {
+	return c.out
+}
+
+// Println writes to the output of the console like fmt.Println.
+// See Stdout for the output of concurrent commands.
+func (c *Console) Println(a ...any) {
+	fmt.Fprintln(c.out, a...)
+}
+
+// Printf writes to the output of the console like fmt.Printf.
+// See Stdout for the output of concurrent commands.
+func (c *Console) Printf(format string, a ...any) {
+	fmt.Fprintf(c.out, format, a...)
+}
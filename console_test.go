@@ -1,12 +1,32 @@
 package console_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/jon4hz/console"
 	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestEmptyConsole(t *testing.T) {
@@ -73,3 +93,1830 @@ func TestMismatchEchoCmd(t *testing.T) {
 	assert.False(t, echoCmd.Match("foo"))
 	assert.False(t, echoCmd.Match("foo test"))
 }
+
+func TestCompleteArgValues(t *testing.T) {
+	c, err := console.New()
+	assert.NoError(t, err)
+	defer c.Close()
+
+	err = c.RegisterCommands(&console.Cmd{
+		Name: "mode",
+		Args: []*console.Arg{{Name: "mode", Values: []string{"fast", "safe", "slow"}}},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"mode fast", "mode safe", "mode slow"}, c.Complete("mode "))
+	assert.Equal(t, []string{"mode safe", "mode slow"}, c.Complete("mode s"))
+	assert.Empty(t, c.Complete("mode fast "))
+}
+
+func TestUndo(t *testing.T) {
+	c, err := console.New()
+	assert.NoError(t, err)
+	defer c.Close()
+
+	assert.ErrorIs(t, c.Undo(), console.ErrNothingToUndo)
+
+	var undone []string
+	c.RegisterUndo("first", func() error { undone = append(undone, "first"); return nil })
+	c.RegisterUndo("second", func() error { undone = append(undone, "second"); return nil })
+
+	assert.NoError(t, c.Undo())
+	assert.NoError(t, c.Undo())
+	assert.Equal(t, []string{"second", "first"}, undone)
+	assert.ErrorIs(t, c.Undo(), console.ErrNothingToUndo)
+
+	// concurrent commands share the stack
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.RegisterUndo("op", func() error { return nil })
+			assert.NoError(t, c.Undo())
+		}()
+	}
+	wg.Wait()
+	assert.ErrorIs(t, c.Undo(), console.ErrNothingToUndo)
+}
+
+func TestAuditLog(t *testing.T) {
+	var buf bytes.Buffer
+	c, err := console.New(console.WithAuditLog(&buf))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	err = c.RegisterCommands(&console.Cmd{
+		Name: "fail",
+		Handler: func(c *console.Console, args []string) error {
+			return errors.New("boom")
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = c.HandleInput("fail now")
+	assert.NoError(t, err)
+
+	var e console.AuditEntry
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &e))
+	assert.Equal(t, "fail now", e.Input)
+	assert.Equal(t, "fail", e.Command)
+	assert.Equal(t, "boom", e.Error)
+}
+
+func TestStats(t *testing.T) {
+	c, err := console.New()
+	assert.NoError(t, err)
+	defer c.Close()
+
+	err = c.RegisterCommands(&console.Cmd{
+		Name:    "noop",
+		Handler: func(c *console.Console, args []string) error { return nil },
+	})
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = c.HandleInput("noop")
+		assert.NoError(t, err)
+	}
+	stats := c.Stats().Snapshot()
+	assert.Len(t, stats, 1)
+	assert.Equal(t, "noop", stats[0].Name)
+	assert.EqualValues(t, 3, stats[0].Calls)
+	assert.Zero(t, stats[0].Errors)
+}
+
+func TestTracing(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	c, err := console.New(console.WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	var traced bool
+	err = c.RegisterCommands(&console.Cmd{
+		Name: "traced",
+		ContextHandler: func(ctx context.Context, c *console.Console, args []string) error {
+			traced = trace.SpanContextFromContext(ctx).IsValid()
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = c.HandleInput("traced a b")
+	assert.NoError(t, err)
+	assert.True(t, traced)
+
+	spans := sr.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "console traced", spans[0].Name())
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c, err := console.New(console.WithLogger(logger))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.HandleInput("unknown")
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "no command matched")
+}
+
+func TestPanicRecovery(t *testing.T) {
+	var buf bytes.Buffer
+	c, err := console.New(console.WithAuditLog(&buf))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	err = c.RegisterCommands(&console.Cmd{
+		Name:    "panic",
+		Handler: func(c *console.Console, args []string) error { panic("oops") },
+	})
+	assert.NoError(t, err)
+
+	assert.NotPanics(t, func() {
+		_, err = c.HandleInput("panic")
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "panic: oops")
+}
+
+func TestRepanic(t *testing.T) {
+	c, err := console.New(console.WithRepanic(true))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	err = c.RegisterCommands(&console.Cmd{
+		Name:    "panic",
+		Handler: func(c *console.Console, args []string) error { panic("oops") },
+	})
+	assert.NoError(t, err)
+
+	assert.Panics(t, func() {
+		_, _ = c.HandleInput("panic")
+	})
+}
+
+func TestPermission(t *testing.T) {
+	c, err := console.New()
+	assert.NoError(t, err)
+	defer c.Close()
+
+	var ran bool
+	err = c.RegisterCommands(&console.Cmd{
+		Name:        "secret",
+		Description: "Restricted command",
+		Permission:  func(c *console.Console) bool { return false },
+		Handler:     func(c *console.Console, args []string) error { ran = true; return nil },
+	})
+	assert.NoError(t, err)
+
+	assert.Empty(t, c.Complete("sec"))
+	assert.NotContains(t, c.RenderHelp(), "secret")
+	_, err = c.HandleInput("secret")
+	assert.NoError(t, err)
+	assert.False(t, ran)
+}
+
+func TestAuthFailure(t *testing.T) {
+	c, err := console.New(console.WithAuth(func(c *console.Console) (console.Identity, error) {
+		return console.Identity{}, errors.New("invalid token")
+	}))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	assert.ErrorIs(t, c.Start(), console.ErrUnauthenticated)
+}
+
+func TestTokenAuth(t *testing.T) {
+	auth := console.TokenAuth(
+		func() (string, error) { return "secret", nil },
+		func(token string) (console.Identity, error) {
+			if token != "secret" {
+				return console.Identity{}, errors.New("invalid token")
+			}
+			return console.Identity{Name: "bot", Roles: []string{"admin"}}, nil
+		},
+	)
+	id, err := auth(nil)
+	assert.NoError(t, err)
+	assert.True(t, id.HasRole("viewer", "admin"))
+	assert.False(t, id.HasRole("viewer"))
+}
+
+func TestElevationCmds(t *testing.T) {
+	c, err := console.New(console.WithElevation(console.Elevation{
+		Role:   "admin",
+		Verify: func(c *console.Console, password string) error { return nil },
+	}))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	assert.False(t, c.Elevated())
+	assert.False(t, c.Identity().HasRole("admin"))
+	assert.Equal(t, []string{"enable"}, c.Complete("ena"))
+
+	_, err = console.New(console.WithElevation(console.Elevation{Role: "admin"}))
+	assert.Error(t, err)
+}
+
+func TestSudo(t *testing.T) {
+	c, err := console.New(
+		console.WithLineReader(console.NewPlainReader(strings.NewReader("secret\nwrong\n"), io.Discard)),
+		console.WithElevation(console.Elevation{
+			Role: "admin",
+			Verify: func(c *console.Console, password string) error {
+				if password != "secret" {
+					return errors.New("wrong password")
+				}
+				return nil
+			},
+		}),
+	)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	var got []string
+	assert.NoError(t, c.RegisterCommands(&console.Cmd{
+		Name:       "reboot",
+		Permission: func(c *console.Console) bool { return c.Identity().HasRole("admin") },
+		Handler: func(c *console.Console, args []string) error {
+			got = args
+			return nil
+		},
+	}))
+
+	assert.ErrorIs(t, c.Run(context.Background(), "reboot"), console.ErrPermissionDenied)
+	assert.NoError(t, c.Run(context.Background(), `sudo reboot "in 5 minutes"`))
+	assert.Equal(t, []string{"in 5 minutes"}, got)
+	assert.False(t, c.Elevated())
+	assert.ErrorIs(t, c.Run(context.Background(), "sudo reboot"), console.ErrUnauthenticated)
+}
+
+func TestRateLimit(t *testing.T) {
+	c, err := console.New()
+	assert.NoError(t, err)
+	defer c.Close()
+
+	var runs int
+	err = c.RegisterCommands(
+		&console.Cmd{
+			Name:      "limited",
+			RateLimit: &console.RateLimit{Calls: 2, Per: time.Minute},
+			Handler:   func(c *console.Console, args []string) error { runs++; return nil },
+		},
+		&console.Cmd{
+			Name:     "cooldown",
+			Cooldown: time.Minute,
+			Handler:  func(c *console.Console, args []string) error { runs++; return nil },
+		},
+	)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = c.HandleInput("limited")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 2, runs)
+
+	for i := 0; i < 2; i++ {
+		_, err = c.HandleInput("cooldown")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 3, runs)
+}
+
+func TestRateLimitEngine(t *testing.T) {
+	var runs int
+	e := console.NewEngine()
+	assert.NoError(t, e.RegisterCommands(&console.Cmd{
+		Name:     "wipe",
+		Danger:   console.DangerHigh,
+		Cooldown: time.Minute,
+		Handler:  func(c *console.Console, args []string) error { runs++; return nil },
+	}))
+	session := func(input string) *console.Console {
+		c, err := e.NewSession(console.WithLineReader(console.NewPlainReader(strings.NewReader(input), io.Discard)))
+		assert.NoError(t, err)
+		t.Cleanup(func() { c.Close() })
+		return c
+	}
+
+	// a command which isn't confirmed doesn't start the cooldown
+	assert.ErrorIs(t, session("no\n").Run(context.Background(), "wipe"), console.ErrNotConfirmed)
+	assert.NoError(t, session("wipe\n").Run(context.Background(), "wipe"))
+	assert.ErrorIs(t, session("wipe\n").Run(context.Background(), "wipe"), console.ErrRateLimited)
+	assert.Equal(t, 1, runs)
+}
+
+func TestRecording(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.cast")
+	c, err := console.New(console.WithRecording(path))
+	assert.NoError(t, err)
+
+	_, err = c.HandleInput("help")
+	assert.NoError(t, err)
+	assert.NoError(t, c.Close())
+
+	var buf bytes.Buffer
+	assert.NoError(t, console.ReplayTo(&buf, path, 1000))
+	assert.Contains(t, buf.String(), "Available commands:\r\n")
+}
+
+type readWriter struct {
+	io.Reader
+	io.Writer
+}
+
+func TestTerminalReader(t *testing.T) {
+	var out bytes.Buffer
+	tr := console.NewTerminalReader(readWriter{strings.NewReader("ec\t hi\r"), &out})
+	tr.SetCompleter(func(line string) []string { return []string{"echo"} })
+
+	line, err := tr.Prompt("> ")
+	assert.NoError(t, err)
+	assert.Equal(t, "echo hi", line)
+
+	_, err = tr.Prompt("> ")
+	assert.ErrorIs(t, err, io.EOF)
+
+	var hist bytes.Buffer
+	_, err = tr.WriteHistory(&hist)
+	assert.NoError(t, err)
+	assert.Equal(t, "echo hi\n", hist.String())
+}
+
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "console.sock")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errC := make(chan error, 1)
+	users := make(chan string, 1)
+	go func() {
+		errC <- console.ListenUnix(ctx, path, 0o600, func(conn net.Conn, opts ...console.Opts) (*console.Console, error) {
+			c, err := console.New(opts...)
+			if err == nil {
+				users <- c.Identity().Name
+			}
+			return c, err
+		})
+	}()
+
+	var conn net.Conn
+	assert.Eventually(t, func() bool {
+		var err error
+		conn, err = net.Dial("unix", path)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	defer conn.Close()
+
+	_, err := conn.Write([]byte("help\nquit\n"))
+	assert.NoError(t, err)
+	out, _ := io.ReadAll(conn)
+	assert.Contains(t, string(out), "Available commands:")
+	if runtime.GOOS == "linux" {
+		u, err := user.Current()
+		assert.NoError(t, err)
+		assert.Equal(t, u.Username, <-users)
+	}
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(path)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+	}
+	// the socket of a running server isn't taken over
+	assert.ErrorContains(t, console.ListenUnix(ctx, path, 0o600, nil), "in use")
+
+	cancel()
+	assert.NoError(t, <-errC)
+	_, err = os.Lstat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestEngineSessions(t *testing.T) {
+	e := console.NewEngine()
+	var mu sync.Mutex
+	seen := make(map[*console.Console]int)
+	err := e.RegisterCommands(&console.Cmd{
+		Name: "count",
+		Handler: func(c *console.Console, args []string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			seen[c]++
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Error(t, e.RegisterCommands(&console.Cmd{Name: "count"}))
+	// builtins can be replaced
+	assert.NoError(t, e.RegisterCommands(&console.Cmd{Name: "help"}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		c, err := e.NewSession(console.WithLineReader(console.NewPlainReader(strings.NewReader(""), io.Discard)))
+		assert.NoError(t, err)
+		defer c.Close()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				_, err := c.HandleInput("count")
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, seen, 2)
+	for _, n := range seen {
+		assert.Equal(t, 10, n)
+	}
+
+	// commands of an engine don't belong to a console
+	shared := &console.Cmd{Name: "shared", Handler: func(*console.Console, []string) error { return nil }}
+	assert.NoError(t, e.RegisterCommands(shared))
+	assert.ErrorIs(t, shared.Handle("shared"), console.ErrCmdNoConsole)
+}
+
+func TestStore(t *testing.T) {
+	c, err := console.New()
+	assert.NoError(t, err)
+	defer c.Close()
+
+	c.Set("cluster", "prod")
+	c.Set("replicas", 3)
+
+	cluster, ok := console.GetAs[string](c, "cluster")
+	assert.True(t, ok)
+	assert.Equal(t, "prod", cluster)
+
+	_, ok = console.GetAs[string](c, "replicas")
+	assert.False(t, ok)
+
+	c.Delete("cluster")
+	_, ok = c.Get("cluster")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"replicas"}, c.Keys())
+}
+
+func TestDangerConfirmation(t *testing.T) {
+	c, err := console.New(console.WithLineReader(console.NewPlainReader(strings.NewReader("staging\nprod\n"), io.Discard)))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	var deleted []string
+	err = c.RegisterCommands(&console.Cmd{
+		Name:   "delete",
+		Danger: console.DangerCritical,
+		Handler: func(c *console.Console, args []string) error {
+			deleted = append(deleted, args[0])
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = c.HandleInput("delete prod")
+	assert.NoError(t, err)
+	assert.Empty(t, deleted)
+
+	_, err = c.HandleInput("delete prod")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"prod"}, deleted)
+}
+
+func TestTimeout(t *testing.T) {
+	var buf bytes.Buffer
+	c, err := console.New(console.WithAuditLog(&buf), console.WithDefaultTimeout(10*time.Millisecond))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	err = c.RegisterCommands(&console.Cmd{
+		Name: "hang",
+		ContextHandler: func(ctx context.Context, c *console.Console, args []string) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = c.HandleInput("hang")
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), console.ErrCmdTimeout.Error())
+}
+
+func TestConcurrency(t *testing.T) {
+	var audit, out bytes.Buffer
+	var mu sync.Mutex
+	c, err := console.New(console.WithAuditLog(&audit), console.WithOutput(&lockedWriter{w: &out, mu: &mu}))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	release := make(chan struct{})
+	err = c.RegisterCommands(
+		&console.Cmd{
+			Name:        "bg",
+			Concurrency: console.ConcurrencyConcurrent,
+			ContextHandler: func(ctx context.Context, c *console.Console, args []string) error {
+				fmt.Fprintln(c.Writer(ctx), "working")
+				<-release
+				return nil
+			},
+		},
+		&console.Cmd{
+			Name:        "exclusive",
+			Concurrency: console.ConcurrencyReject,
+			Handler:     func(c *console.Console, args []string) error { return nil },
+		},
+	)
+	assert.NoError(t, err)
+
+	_, err = c.HandleInput("bg")
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return strings.Contains(out.String(), "[bg#1] working")
+	}, time.Second, time.Millisecond)
+
+	_, err = c.HandleInput("exclusive")
+	assert.NoError(t, err)
+	close(release)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return strings.Contains(out.String(), "[bg#1] done")
+	}, time.Second, time.Millisecond)
+	assert.Contains(t, audit.String(), console.ErrCmdBusy.Error())
+}
+
+func TestConcurrentBuiltins(t *testing.T) {
+	var out bytes.Buffer
+	var mu sync.Mutex
+	c, err := console.New(console.WithConcurrency(console.ConcurrencyConcurrent), console.WithOutput(&lockedWriter{w: &out, mu: &mu}))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.HandleInput("calc 1+2")
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return strings.Contains(out.String(), "[calc#1] 3\n")
+	}, time.Second, time.Millisecond)
+}
+
+type lockedWriter struct {
+	w  io.Writer
+	mu *sync.Mutex
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+func TestConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "console.yaml")
+	assert.NoError(t, os.WriteFile(yamlPath, []byte(`
+prompt: "app> "
+history:
+  file: ""
+aliases:
+  h: help
+disabled_builtins: [stats]
+`), 0o600))
+
+	var out bytes.Buffer
+	c, err := console.NewFromConfig(yamlPath, console.WithOutput(&out))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	assert.Empty(t, c.Complete("sta"))
+	assert.Equal(t, []string{"help", "h"}, c.Complete("h"))
+	_, err = c.HandleInput("h")
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "Available commands:")
+
+	tomlPath := filepath.Join(dir, "console.toml")
+	assert.NoError(t, os.WriteFile(tomlPath, []byte("[keybindings]\ntab = \"bogus\"\n"), 0o600))
+	_, err = console.NewFromConfig(tomlPath)
+	assert.Error(t, err)
+}
+
+func TestEnvOverrides(t *testing.T) {
+	t.Setenv("MYAPP_TIMEOUT", "nope")
+	_, err := console.New(console.WithEnvPrefix("MYAPP"))
+	assert.Error(t, err)
+
+	t.Setenv("MYAPP_TIMEOUT", "")
+	c, err := console.New(console.WithEnvPrefix(""))
+	assert.NoError(t, err)
+	c.Close()
+
+	history := filepath.Join(t.TempDir(), "history")
+	t.Setenv("MYAPP_TIMEOUT", "30s")
+	t.Setenv("MYAPP_PROMPT", "env> ")
+	t.Setenv("MYAPP_HISTORY_FILE", history)
+	t.Setenv("MYAPP_NO_COLOR", "1")
+	c, err = console.New(console.WithEnvPrefix("MYAPP"))
+	assert.NoError(t, err)
+	assert.Equal(t, "env> ", c.Prompt())
+	assert.Equal(t, history, c.HistoryFile())
+	assert.Equal(t, lipgloss.NoColor{}, c.Theme().Error.GetForeground())
+	c.Close()
+
+	// the environment wins over options
+	c, err = console.New(console.WithEnvPrefix("MYAPP"), console.WithPrompt("opt> "), console.WithHistoryFile(""), console.WithTheme(console.DefaultTheme()))
+	assert.NoError(t, err)
+	assert.Equal(t, "env> ", c.Prompt())
+	assert.Equal(t, history, c.HistoryFile())
+	assert.Equal(t, lipgloss.NoColor{}, c.Theme().Error.GetForeground())
+	c.Close()
+
+	// neither sessions nor children read the environment
+	c, err = console.NewEngine(console.WithHistoryFile("")).NewSession()
+	assert.NoError(t, err)
+	assert.Equal(t, "> ", c.Prompt())
+	t.Setenv("CONSOLE_PROMPT", "env> ")
+	child, err := c.NewChild()
+	assert.NoError(t, err)
+	assert.Equal(t, "> ", child.Prompt())
+	c.Close()
+}
+
+type deployCmd struct {
+	_       struct{} `cmd:"deploy" aliases:"d" help:"Deploy a service"`
+	Env     string   `flag:"env" short:"e" default:"staging" enum:"staging,prod" help:"Target environment"`
+	Force   bool     `flag:"force" help:"Skip the checks"`
+	Service string   `arg:"service" required:"true" help:"Service to deploy"`
+
+	deployed *[]string
+}
+
+func (d *deployCmd) Run(_ context.Context, c *console.Console) error {
+	*d.deployed = append(*d.deployed, fmt.Sprintf("%s@%s force=%t", d.Service, d.Env, d.Force))
+	return nil
+}
+
+func TestRegisterStruct(t *testing.T) {
+	var out bytes.Buffer
+	c, err := console.New(console.WithOutput(&out))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	var deployed []string
+	assert.NoError(t, c.RegisterStruct(&deployCmd{deployed: &deployed}))
+
+	_, err = c.HandleInput("deploy api")
+	assert.NoError(t, err)
+	_, err = c.HandleInput("d -e prod --force web")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"api@staging force=false", "web@prod force=true"}, deployed)
+
+	_, err = c.HandleInput("deploy --env test api")
+	assert.NoError(t, err)
+	_, err = c.HandleInput("deploy")
+	assert.NoError(t, err)
+	assert.Len(t, deployed, 2)
+	assert.Contains(t, out.String(), "argument <service> is required")
+
+	_, err = c.HandleInput("deploy --help")
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "Usage: deploy [flags] <service>")
+
+	assert.Equal(t, []string{"deploy --env", "deploy --force"}, c.Complete("deploy --"))
+	assert.Equal(t, []string{"deploy --env prod"}, c.Complete("deploy --env p"))
+
+	// nothing is registered if one of the commands is invalid
+	assert.Error(t, c.RegisterStruct(&statusCmd{}, &badCmd{}))
+	assert.Empty(t, c.Complete("statu"))
+}
+
+type statusCmd struct{}
+
+func (*statusCmd) Run(context.Context, *console.Console) error { return nil }
+
+type badCmd struct {
+	C chan int `flag:"c"`
+}
+
+func (*badCmd) Run(context.Context, *console.Console) error { return nil }
+
+type userSvc struct {
+	users []string
+}
+
+func (s *userSvc) CmdAddUser(_ context.Context, args []string) error {
+	s.users = append(s.users, args...)
+	return nil
+}
+
+func (s *userSvc) CmdListUsers(_ context.Context, _ []string) error {
+	return nil
+}
+
+func (s *userSvc) CmdDocs() map[string]string {
+	return map[string]string{"add-user": "Add users"}
+}
+
+func TestRegisterMethods(t *testing.T) {
+	c, err := console.New()
+	assert.NoError(t, err)
+	defer c.Close()
+
+	svc := &userSvc{}
+	assert.NoError(t, c.RegisterMethods(svc))
+	assert.Equal(t, []string{"add-user"}, c.Complete("add"))
+	assert.Equal(t, []string{"list-users"}, c.Complete("list"))
+	assert.Contains(t, c.RenderHelp(), "Add users")
+
+	_, err = c.HandleInput("add-user alice bob")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, svc.users)
+
+	assert.Error(t, c.RegisterMethods(struct{}{}))
+}
+
+func TestRun(t *testing.T) {
+	c, err := console.New()
+	assert.NoError(t, err)
+	defer c.Close()
+
+	assert.NoError(t, c.RegisterCommands(&console.Cmd{
+		Name:    "fail",
+		Handler: func(*console.Console, []string) error { return errors.New("failed") },
+	}))
+	assert.EqualError(t, c.Run(context.Background(), "fail"), "failed")
+	assert.ErrorIs(t, c.Run(context.Background(), "missing"), console.ErrCmdNotFound)
+	// the name is matched like the arguments are split
+	assert.EqualError(t, c.Run(context.Background(), "fail\tnow"), "failed")
+	assert.EqualError(t, c.Run(context.Background(), `"fail" now`), "failed")
+}
+
+func TestCalc(t *testing.T) {
+	var out bytes.Buffer
+	c, err := console.New(console.WithOutput(&out))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	c.Set("n", 4)
+	c.Set("rate", "0.5")
+	for expr, want := range map[string]float64{
+		"1 + 2 * 3":              7,
+		"(1 + 2) * 3":            9,
+		"-2 ^ 2":                 -4,
+		"2 ^ 3 ^ 2":              512,
+		"7 % 4":                  3,
+		"$n * rate":              2,
+		"max(1, n, 3) + abs(-1)": 5,
+		"1.5e2":                  150,
+	} {
+		v, err := c.Eval(expr)
+		assert.NoError(t, err, expr)
+		assert.Equal(t, want, v, expr)
+	}
+	for _, expr := range []string{"1 / 0", "1 +", "(1", "x", "foo(1)", "1 2"} {
+		_, err := c.Eval(expr)
+		assert.Error(t, err, expr)
+	}
+
+	assert.NoError(t, c.Run(context.Background(), "= 6 * 7"))
+	assert.NoError(t, c.Run(context.Background(), "calc $_ / 2"))
+	assert.Equal(t, "42\n21\n", out.String())
+	v, _ := c.Get("_")
+	assert.Equal(t, float64(21), v)
+}
+
+type copyParams struct {
+	DryRun  bool
+	Retries int           `flag:"retries" short:"r" default:"3"`
+	Wait    time.Duration `flag:"wait"`
+	Paths   []string      `arg:"paths" required:"true"`
+}
+
+func TestBind(t *testing.T) {
+	c, err := console.New()
+	assert.NoError(t, err)
+	defer c.Close()
+
+	var got copyParams
+	assert.NoError(t, c.RegisterCommands(console.Bind(&console.Cmd{Name: "copy"}, func(_ *console.Console, p *copyParams) error {
+		got = *p
+		return nil
+	})))
+	assert.NoError(t, c.Run(context.Background(), "copy --dry-run --wait 1s a b"))
+	assert.Equal(t, copyParams{DryRun: true, Retries: 3, Wait: time.Second, Paths: []string{"a", "b"}}, got)
+	assert.NoError(t, c.Run(context.Background(), "copy -r 5 c"))
+	assert.Equal(t, copyParams{Retries: 5, Paths: []string{"c"}}, got)
+	assert.Error(t, c.Run(context.Background(), "copy --retries x c"))
+	assert.Error(t, c.Run(context.Background(), "copy"))
+	assert.Equal(t, []string{"copy --dry-run"}, c.Complete("copy --d"))
+
+	assert.Error(t, c.RegisterCommands(console.Bind(&console.Cmd{Name: "bad"}, func(*console.Console, *struct{ C chan int }) error {
+		return nil
+	})))
+}
+
+func TestBindSliceDefault(t *testing.T) {
+	c, err := console.New()
+	assert.NoError(t, err)
+	defer c.Close()
+
+	type params struct {
+		Tags  []string `flag:"tag" default:"a,b"`
+		Paths []string `arg:"paths" default:"."`
+	}
+	var got params
+	assert.NoError(t, c.RegisterCommands(console.Bind(&console.Cmd{Name: "tag"}, func(_ *console.Console, p *params) error {
+		got = *p
+		return nil
+	})))
+	assert.NoError(t, c.Run(context.Background(), "tag"))
+	assert.Equal(t, params{Tags: []string{"a", "b"}, Paths: []string{"."}}, got)
+	assert.NoError(t, c.Run(context.Background(), "tag --tag c --tag d x y"))
+	assert.Equal(t, params{Tags: []string{"c", "d"}, Paths: []string{"x", "y"}}, got)
+}
+
+func TestModes(t *testing.T) {
+	// not reading from stdin, which is no terminal under go test
+	c, err := console.New(console.WithPrompt("router> "), console.WithLineReader(console.NewPlainReader(strings.NewReader(""), io.Discard)))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	var hostname string
+	assert.NoError(t, c.RegisterCommands(&console.Cmd{
+		Name:    "configure",
+		Handler: func(c *console.Console, _ []string) error { return c.EnterMode("config") },
+	}))
+	assert.NoError(t, c.RegisterMode(&console.Mode{
+		Name: "config",
+		Cmds: []*console.Cmd{{
+			Name: "hostname",
+			Handler: func(_ *console.Console, args []string) error {
+				hostname = args[0]
+				return nil
+			},
+		}},
+	}))
+	assert.Error(t, c.RegisterMode(&console.Mode{Name: "config"}))
+	assert.ErrorIs(t, c.EnterMode("bogus"), console.ErrUnknownMode)
+
+	assert.ErrorIs(t, c.Run(context.Background(), "hostname r1"), console.ErrCmdNotFound)
+	_, err = c.HandleInput("configure")
+	assert.NoError(t, err)
+	assert.Equal(t, "config", c.Mode())
+	assert.Equal(t, "router(config)> ", c.Prompt())
+	assert.Equal(t, []string{"hostname", "help"}, c.Complete("h"))
+
+	_, err = c.HandleInput("hostname r1")
+	assert.NoError(t, err)
+	assert.Equal(t, "r1", hostname)
+
+	exit, err := c.HandleInput("exit")
+	assert.NoError(t, err)
+	assert.False(t, exit)
+	assert.Equal(t, "", c.Mode())
+	assert.ErrorIs(t, c.ExitMode(), console.ErrNoMode)
+}
+
+func TestPushChild(t *testing.T) {
+	var prompts bytes.Buffer
+	in := strings.NewReader("db\nselect 1\nquit\nhello\n")
+	c, err := console.New(console.WithLineReader(console.NewPlainReader(in, &prompts)), console.WithHistoryFile(""))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	var calls []string
+	assert.NoError(t, c.RegisterCommands(
+		&console.Cmd{
+			Name: "db",
+			Handler: func(c *console.Console, _ []string) error {
+				child, err := c.NewChild(console.WithPrompt("sql> "))
+				if err != nil {
+					return err
+				}
+				if err := child.RegisterCommands(&console.Cmd{
+					Name: "select",
+					Handler: func(_ *console.Console, args []string) error {
+						calls = append(calls, "select "+args[0])
+						return nil
+					},
+				}); err != nil {
+					return err
+				}
+				return c.Push(child)
+			},
+		},
+		&console.Cmd{
+			Name: "hello",
+			Handler: func(*console.Console, []string) error {
+				calls = append(calls, "hello")
+				return nil
+			},
+		},
+	))
+	assert.NoError(t, c.Start())
+	assert.Equal(t, []string{"select 1", "hello"}, calls)
+	assert.Equal(t, "> sql> sql> > > ", prompts.String())
+
+	other, err := console.New()
+	assert.NoError(t, err)
+	defer other.Close()
+	assert.Error(t, c.Push(other))
+}
+
+func TestNamespace(t *testing.T) {
+	var out bytes.Buffer
+	c, err := console.New(console.WithOutput(&out))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	var got []string
+	show := &console.Cmd{
+		Name:        "show",
+		Aliases:     []string{"sh"},
+		Description: "Show interfaces",
+		Args:        []*console.Arg{{Name: "iface", Values: []string{"eth0", "eth1"}}},
+		Handler: func(_ *console.Console, args []string) error {
+			got = append(got, strings.Join(args, ","))
+			return nil
+		},
+	}
+	assert.NoError(t, c.RegisterNamespace("net", show))
+	assert.NoError(t, c.RegisterNamespace("net", &console.Cmd{Name: "config", Description: "Configure"}))
+	assert.Error(t, c.RegisterNamespace("net", &console.Cmd{Name: "show"}))
+	assert.Error(t, c.RegisterCommands(&console.Cmd{Name: "net"}))
+
+	assert.NoError(t, c.Run(context.Background(), "net show eth0"))
+	assert.NoError(t, c.Run(context.Background(), "net\tsh"))
+	assert.Equal(t, []string{"eth0", ""}, got)
+	assert.EqualError(t, c.Run(context.Background(), "net bogus"), "unknown command net bogus")
+	assert.Equal(t, "net show", show.Name)
+
+	assert.Equal(t, []string{"net"}, c.Complete("ne"))
+	assert.Equal(t, []string{"net show", "net sh"}, c.Complete("net s"))
+	assert.Equal(t, []string{"net show eth0", "net show eth1"}, c.Complete("net show "))
+	assert.Contains(t, c.RenderHelp(), "net show - Show interfaces")
+	assert.Contains(t, c.RenderHelp(), "net config - Configure")
+}
+
+func TestEvents(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	record := func(e console.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		s := e.Type.String()
+		if e.Command != nil {
+			s += " " + e.Command.Name
+		}
+		if e.Err != nil {
+			s += " " + e.Err.Error()
+		}
+		events = append(events, s)
+	}
+	c, err := console.New(
+		console.WithLineReader(console.NewPlainReader(strings.NewReader("fail\n"), io.Discard)),
+		console.WithHistoryFile(""),
+		console.WithHook(console.EventStart, record),
+		console.WithHook(console.EventPrompt, record),
+		console.WithHook(console.EventExit, record),
+		console.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	)
+	assert.NoError(t, err)
+	for _, typ := range []console.EventType{console.EventCommandStart, console.EventCommandEnd, console.EventError} {
+		c.On(typ, record)
+	}
+	unsubscribe := c.On(console.EventPrompt, func(console.Event) { panic("broken hook") })
+	unsubscribe()
+	c.On(console.EventStart, func(console.Event) { panic("broken hook") })
+
+	assert.NoError(t, c.RegisterCommands(&console.Cmd{
+		Name:    "fail",
+		Handler: func(*console.Console, []string) error { return errors.New("failed") },
+	}))
+	assert.NoError(t, c.Start())
+	c.Close()
+	c.Close()
+	assert.Equal(t, []string{
+		"start",
+		"prompt",
+		"command_start fail",
+		"command_end fail failed",
+		"error fail failed",
+		"prompt",
+		"exit",
+	}, events)
+}
+
+func TestMessages(t *testing.T) {
+	for _, tag := range console.Locales() {
+		for id := range console.LocaleMessages("en") {
+			assert.NotEmpty(t, console.LocaleMessages(tag)[id], "%s: %s", tag, id)
+		}
+	}
+
+	_, err := console.New(console.WithLocale("xx"))
+	assert.Error(t, err)
+
+	c, err := console.New(
+		console.WithLocale("de"),
+		console.WithMessages(console.Messages{console.MsgClearDescription: "Aufräumen"}),
+	)
+	assert.NoError(t, err)
+	defer c.Close()
+	help := c.RenderHelp()
+	assert.True(t, strings.HasPrefix(help, "Verfügbare Befehle:"))
+	assert.Contains(t, help, "help - Hilfe anzeigen")
+	assert.Contains(t, help, "clear - Aufräumen")
+	assert.Contains(t, help, "quit - Konsole beenden")
+
+	assert.EqualError(t, c.Run(context.Background(), "help -k"), "Aufruf: help -k <keyword>")
+	assert.NoError(t, c.RegisterNamespace("net", &console.Cmd{Name: "show", Args: []*console.Arg{{Name: "what", Values: []string{"routes"}}}}))
+	for _, cmd := range c.Catalog() {
+		if cmd.Name == "net" {
+			assert.Equal(t, "net-Befehle", cmd.Description)
+		}
+	}
+	assert.EqualError(t, c.Run(context.Background(), "net bogus"), "unbekannter Befehl net bogus")
+	page, err := c.RenderManPage("net", "show")
+	assert.NoError(t, err)
+	assert.Contains(t, page, "Aufruf: net show [<what>]\n\n    Argumente:\n      what                 (eines von routes)")
+}
+
+func TestVersion(t *testing.T) {
+	c, err := console.New()
+	assert.NoError(t, err)
+	_, ok := c.Version()
+	assert.False(t, ok)
+	assert.Empty(t, c.Complete("vers"))
+	c.Close()
+
+	// braces which aren't a template are printed as they are
+	var out bytes.Buffer
+	c, err = console.New(
+		console.WithWelcomeMsg("Press {{ to start"),
+		console.WithLineReader(console.NewPlainReader(strings.NewReader(""), io.Discard)),
+		console.WithHistoryFile(""),
+		console.WithOutput(&out),
+	)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Start())
+	assert.Equal(t, "Press {{ to start\n", out.String())
+	c.Close()
+
+	out.Reset()
+	c, err = console.New(
+		console.WithVersion("app", "1.2.3", "abc123", "2024-01-02"),
+		console.WithWelcomeMsg("Welcome to {{.Name}} {{.Version}}"),
+		console.WithLineReader(console.NewPlainReader(strings.NewReader("version\nversion --output json\nformat yaml\nversion\n"), io.Discard)),
+		console.WithHistoryFile(""),
+		console.WithOutput(&out),
+	)
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.Start())
+	assert.Equal(t, "Welcome to app 1.2.3\n"+
+		"app 1.2.3 (commit abc123, built 2024-01-02)\n"+
+		"{\n  \"name\": \"app\",\n  \"version\": \"1.2.3\",\n  \"commit\": \"abc123\",\n  \"date\": \"2024-01-02\"\n}\n"+
+		"name: app\nversion: 1.2.3\ncommit: abc123\ndate: \"2024-01-02\"\n", out.String())
+	assert.Error(t, c.Run(context.Background(), "version --json"))
+}
+
+func TestReplaceBuiltins(t *testing.T) {
+	c, err := console.New(console.WithoutDefaultCmds())
+	assert.NoError(t, err)
+	assert.Empty(t, c.Complete("he"))
+	assert.Equal(t, []string{"quit"}, c.Complete("q"))
+	c.Close()
+
+	c, err = console.New()
+	assert.NoError(t, err)
+	defer c.Close()
+
+	var calls []string
+	record := func(c *console.Console, _ []string) error {
+		calls = append(calls, c.Ctx().Err().Error())
+		return nil
+	}
+	help := &console.Cmd{Name: "help", Description: "Custom help", Handler: func(*console.Console, []string) error {
+		calls = append(calls, "help")
+		return nil
+	}}
+	assert.NoError(t, c.RegisterCommands(help))
+	assert.Error(t, c.RegisterCommands(&console.Cmd{Name: "help"}))
+	assert.NoError(t, c.RegisterCommands(&console.Cmd{Name: "exit", Handler: func(c *console.Console, args []string) error {
+		c.Close()
+		return record(c, args)
+	}}))
+
+	assert.NoError(t, c.Run(context.Background(), "help"))
+	assert.Contains(t, c.RenderHelp(), "help - Custom help")
+	assert.NotContains(t, c.RenderHelp(), "Show the help")
+	assert.Equal(t, []string{"help"}, c.Complete("hel"))
+
+	exit, err := c.HandleInput("exit")
+	assert.NoError(t, err)
+	assert.True(t, exit)
+	assert.Equal(t, []string{"help", "context canceled"}, calls)
+
+	c.UnregisterCommands(help)
+	assert.Contains(t, c.RenderHelp(), "Show the help")
+}
+
+func TestRegistrationErrors(t *testing.T) {
+	c, err := console.New()
+	assert.NoError(t, err)
+	defer c.Close()
+
+	list := &console.Cmd{Name: "list", Aliases: []string{"ls"}}
+	assert.NoError(t, c.RegisterCommands(list))
+
+	err = c.RegisterCommands(
+		&console.Cmd{Name: "dir", Aliases: []string{"ls"}},
+		&console.Cmd{Name: "list"},
+		&console.Cmd{Name: "bad name"},
+		&console.Cmd{Name: "stat", Aliases: []string{""}},
+		&console.Cmd{Name: "dir"},
+	)
+	assert.ErrorIs(t, err, console.ErrCmdRegistered)
+	assert.ErrorIs(t, err, console.ErrInvalidCmdName)
+
+	var regErr *console.RegistrationError
+	assert.ErrorAs(t, err, &regErr)
+	assert.Len(t, regErr.Errs, 5)
+	conflicts := regErr.Conflicts()
+	assert.Len(t, conflicts, 3)
+	assert.Equal(t, "ls", conflicts[0].Name)
+	assert.Same(t, list, conflicts[0].Existing)
+	assert.EqualError(t, conflicts[0], `command "dir": alias "ls" collides with alias of command "list"`)
+	assert.EqualError(t, conflicts[1], `command "list": name "list" collides with command "list"`)
+	assert.Equal(t, "dir", conflicts[2].Existing.Name)
+	assert.EqualError(t, regErr.Errs[2], `command "bad name": name "bad name" contains whitespace`)
+
+	// nothing of a rejected registration is registered
+	assert.NoError(t, c.RegisterCommands(&console.Cmd{Name: "stat"}))
+	assert.Error(t, c.RegisterNamespace("net", &console.Cmd{Name: "show"}, &console.Cmd{Name: "show"}))
+	assert.NoError(t, c.RegisterCommands(&console.Cmd{Name: "net"}))
+}
+
+func TestHelpOrder(t *testing.T) {
+	cmds := func() []*console.Cmd {
+		return []*console.Cmd{
+			{Name: "zap", Description: "Zap", Group: "Danger"},
+			{Name: "list", Description: "List"},
+			{Name: "drop", Description: "Drop", Group: "Danger"},
+		}
+	}
+	newConsole := func(o console.HelpOrder) *console.Console {
+		c, err := console.New(console.WithHelpOrder(o), console.WithoutDefaultCmds())
+		assert.NoError(t, err)
+		assert.NoError(t, c.RegisterCommands(cmds()...))
+		assert.NoError(t, c.RegisterNamespace("net", &console.Cmd{Name: "show", Description: "Show"}))
+		return c
+	}
+
+	c := newConsole(console.HelpOrderRegistration)
+	defer c.Close()
+	assert.Equal(t, "Available commands:\n  zap - Zap\n  list - List\n  drop - Drop\n  net show - Show\n  quit - Exit the console", c.RenderHelp())
+
+	c = newConsole(console.HelpOrderAlphabetical)
+	defer c.Close()
+	assert.Equal(t, "Available commands:\n  drop - Drop\n  list - List\n  net show - Show\n  zap - Zap\n  quit - Exit the console", c.RenderHelp())
+
+	c = newConsole(console.HelpOrderGroup)
+	defer c.Close()
+	assert.Equal(t, "Available commands:\n  list - List\n\nDanger:\n  drop - Drop\n  zap - Zap\n\nnet:\n  net show - Show\n\n  quit - Exit the console", c.RenderHelp())
+}
+
+func TestQuotedArgs(t *testing.T) {
+	c, err := console.New()
+	assert.NoError(t, err)
+	defer c.Close()
+
+	var got []string
+	assert.NoError(t, c.RegisterCommands(&console.Cmd{
+		Name:    "echo",
+		Handler: func(c *console.Console, args []string) error { got = args; return nil },
+	}))
+	assert.NoError(t, c.Run(context.Background(), `echo "a b"  'c' {"id":1}`))
+	assert.Equal(t, []string{"a b", "c", `{"id":1}`}, got)
+	assert.Error(t, c.Run(context.Background(), `echo "a`))
+}
+
+func benchConsole(b *testing.B, opts ...console.Opts) *console.Console {
+	c, err := console.New(append([]console.Opts{console.WithOutput(io.Discard), console.WithHistoryFile("")}, opts...)...)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { c.Close() })
+	for i := 0; i < 50; i++ {
+		err := c.RegisterCommands(&console.Cmd{
+			Name:    fmt.Sprintf("cmd%d", i),
+			Aliases: []string{fmt.Sprintf("c%d", i)},
+			Handler: func(c *console.Console, args []string) error { return nil },
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	return c
+}
+
+func BenchmarkDispatch(b *testing.B) {
+	c := benchConsole(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.HandleInput("cmd49 some args here"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPipe(b *testing.B) {
+	script := strings.Repeat("cmd25 a b c\n", b.N)
+	c := benchConsole(b, console.WithLineReader(console.NewPlainReader(strings.NewReader(script), io.Discard)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	if err := c.Start(); err != nil {
+		b.Fatal(err)
+	}
+}
+
+func TestRegisterLazy(t *testing.T) {
+	c, err := console.New()
+	assert.NoError(t, err)
+	defer c.Close()
+
+	var loads int
+	var got []string
+	assert.NoError(t, c.RegisterLazy("deploy", "Deploy a service", func() *console.Cmd {
+		loads++
+		return &console.Cmd{
+			Args:    []*console.Arg{{Name: "env", Values: []string{"prod", "staging"}}},
+			Handler: func(c *console.Console, args []string) error { got = args; return nil },
+		}
+	}))
+	assert.NoError(t, c.RegisterLazy("broken", "Broken", func() *console.Cmd { return nil }))
+
+	assert.Contains(t, c.RenderHelp(), "deploy - Deploy a service")
+	assert.Equal(t, []string{"deploy"}, c.Complete("dep"))
+	assert.Zero(t, loads)
+
+	assert.Equal(t, []string{"deploy prod"}, c.Complete("deploy p"))
+	assert.NoError(t, c.Run(context.Background(), "deploy prod"))
+	assert.NoError(t, c.Run(context.Background(), "deploy staging"))
+	assert.Equal(t, []string{"staging"}, got)
+	assert.Equal(t, 1, loads)
+
+	assert.EqualError(t, c.Run(context.Background(), "broken"), "command broken: not loaded")
+}
+
+func TestLazyNamespace(t *testing.T) {
+	c, err := console.New()
+	assert.NoError(t, err)
+	defer c.Close()
+
+	var shown bool
+	assert.NoError(t, c.RegisterLazy("net", "Network commands", func() *console.Cmd {
+		return console.NamespaceCmd("net", &console.Cmd{
+			Name:    "show",
+			Handler: func(c *console.Console, args []string) error { shown = true; return nil },
+		})
+	}))
+	assert.Equal(t, []string{"net show"}, c.Complete("net s"))
+	assert.NoError(t, c.Run(context.Background(), "net show"))
+	assert.True(t, shown)
+}
+
+func TestWorkingDir(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "src", "app"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "src", "main.go"), nil, 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden"), nil, 0o600))
+
+	var out bytes.Buffer
+	c, err := console.New(console.WithOutput(&out), console.WithWorkingDir(dir), console.WithWorkingDirPrompt(true), console.WithPrompt("app> "))
+	assert.NoError(t, err)
+	defer c.Close()
+	wd, _ := os.Getwd()
+
+	assert.Equal(t, "app:"+dir+"> ", c.Prompt())
+	assert.EqualError(t, c.Run(context.Background(), "cd -"), "no previous directory")
+	assert.EqualError(t, c.Run(context.Background(), "cd a b"), "usage: cd [dir]")
+	assert.Equal(t, []string{"cd src" + string(filepath.Separator)}, c.Complete("cd s"))
+	assert.Empty(t, c.Complete("cd src/m"))
+
+	assert.NoError(t, c.Run(context.Background(), "cd src"))
+	assert.Equal(t, filepath.Join(dir, "src"), c.WorkingDir())
+	assert.Equal(t, filepath.Join(dir, "src", "main.go"), c.ResolvePath("main.go"))
+	assert.Equal(t, []string{"app" + string(filepath.Separator), "main.go"}, console.CompleteFiles(c, []string{""}))
+	assert.ErrorIs(t, c.Run(context.Background(), "cd main.go"), console.ErrNotDir)
+	assert.Error(t, c.Run(context.Background(), "cd missing"))
+
+	assert.NoError(t, c.Run(context.Background(), "cd -"))
+	assert.NoError(t, c.Run(context.Background(), "pwd"))
+	assert.Equal(t, dir+"\n", out.String())
+
+	// the process doesn't change its directory
+	now, _ := os.Getwd()
+	assert.Equal(t, wd, now)
+}
+
+func TestExec(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	var out bytes.Buffer
+	dir := t.TempDir()
+	c, err := console.New(console.WithOutput(&out), console.WithExec(), console.WithWorkingDir(dir))
+	assert.NoError(t, err)
+	defer c.Close()
+	c.Set("GREETING", "hello")
+	c.Set("not valid", "ignored")
+
+	assert.NoError(t, c.Run(context.Background(), `exec sh -c "echo $GREETING; pwd; echo oops >&2"`))
+	assert.Equal(t, "hello\n"+dir+"\noops\n", out.String())
+	out.Reset()
+	assert.NoError(t, c.Run(context.Background(), "exec echo --output wide"))
+	assert.Equal(t, "--output wide\n", out.String())
+	v, _ := c.Get(console.ExitCodeKey)
+	assert.Equal(t, 0, v)
+	assert.EqualError(t, c.Run(context.Background(), "exec"), "usage: exec <command> [args...]")
+
+	code, err := c.Exec(context.Background(), "sh", "-c", "exit 3")
+	var exitErr *exec.ExitError
+	assert.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 3, code)
+	res, err := c.Eval("$? * 2")
+	assert.NoError(t, err)
+	assert.Equal(t, 6.0, res)
+
+	code, err = c.Exec(context.Background(), "does-not-exist")
+	assert.Error(t, err)
+	assert.Equal(t, 127, code)
+}
+
+func TestScheduler(t *testing.T) {
+	var out bytes.Buffer
+	var mu sync.Mutex
+	c, err := console.New(console.WithOutput(&lockedWriter{w: &out, mu: &mu}), console.WithScheduler())
+	assert.NoError(t, err)
+	defer c.Close()
+
+	var runs atomic.Int32
+	err = c.RegisterCommands(&console.Cmd{
+		Name: "status",
+		ContextHandler: func(ctx context.Context, c *console.Console, args []string) error {
+			runs.Add(1)
+			fmt.Fprintln(c.Writer(ctx), "ok")
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, c.Run(context.Background(), "every 10ms nope"), console.ErrCmdNotFound)
+	assert.Error(t, c.Run(context.Background(), "every soon status"))
+	assert.Error(t, c.Run(context.Background(), "at 25:00 status"))
+
+	assert.NoError(t, c.Run(context.Background(), "every 10ms status"))
+	assert.Eventually(t, func() bool { return runs.Load() >= 2 }, time.Second, time.Millisecond)
+	mu.Lock()
+	assert.Contains(t, out.String(), "[job#1] ok\n")
+	mu.Unlock()
+
+	assert.NoError(t, c.Run(context.Background(), "at 23:59:59 status"))
+	jobs := c.Jobs()
+	if assert.Len(t, jobs, 2) {
+		assert.Equal(t, "every 10ms", jobs[0].Spec)
+		assert.Equal(t, "status", jobs[1].Line)
+		assert.True(t, jobs[1].Next.After(time.Now()))
+	}
+
+	assert.NoError(t, c.Run(context.Background(), "schedule cancel 1"))
+	assert.ErrorIs(t, c.Run(context.Background(), "schedule cancel 1"), console.ErrUnknownJob)
+	assert.EqualError(t, c.Run(context.Background(), "schedule cancel x"), `invalid job "x"`)
+	assert.EqualError(t, c.Run(context.Background(), "every 1m"), "usage: every <interval> <command> [args...]")
+	n := runs.Load()
+	time.Sleep(30 * time.Millisecond)
+	assert.LessOrEqual(t, runs.Load(), n+1)
+
+	_, err = c.At(time.Now().Add(10*time.Millisecond), "status")
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return len(c.Jobs()) == 1 }, time.Second, time.Millisecond)
+
+	assert.Equal(t, []string{"schedule cancel 2"}, c.Complete("schedule cancel "))
+}
+
+func TestSchedulerInteractive(t *testing.T) {
+	elevation := console.WithElevation(console.Elevation{
+		Role:   "admin",
+		Verify: func(c *console.Console, password string) error { return nil },
+	})
+	wipe := &console.Cmd{Name: "wipe", Danger: console.DangerHigh, Handler: func(c *console.Console, args []string) error { return nil }}
+
+	c, err := console.New(console.WithScheduler(), elevation)
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.RegisterCommands(wipe))
+	assert.ErrorIs(t, c.Run(context.Background(), "every 1m wipe"), console.ErrInteractiveJob)
+	assert.ErrorIs(t, c.Run(context.Background(), "every 1m sudo help"), console.ErrInteractiveJob)
+	assert.Empty(t, c.Jobs())
+
+	c, err = console.New(console.WithScheduler(), console.WithConfirmPolicy(console.DangerHigh, console.ConfirmNone))
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.RegisterCommands(wipe))
+	assert.NoError(t, c.Run(context.Background(), "every 1m wipe"))
+}
+
+func TestSchedulerElevation(t *testing.T) {
+	var out bytes.Buffer
+	var mu sync.Mutex
+	c, err := console.New(
+		console.WithScheduler(),
+		console.WithOutput(&lockedWriter{w: &out, mu: &mu}),
+		console.WithLineReader(console.NewPlainReader(strings.NewReader("secret\n"), io.Discard)),
+		console.WithElevation(console.Elevation{
+			Role:   "admin",
+			Verify: func(c *console.Console, password string) error { return nil },
+		}),
+	)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	var runs, privileged atomic.Int32
+	assert.NoError(t, c.RegisterCommands(
+		&console.Cmd{Name: "status", Handler: func(c *console.Console, args []string) error {
+			if c.Identity().HasRole("admin") {
+				privileged.Add(1)
+			}
+			runs.Add(1)
+			return nil
+		}},
+		&console.Cmd{
+			Name:       "reboot",
+			Permission: func(c *console.Console) bool { return c.Identity().HasRole("admin") },
+			Handler:    func(c *console.Console, args []string) error { return nil },
+		},
+	))
+
+	// scheduled unelevated, the jobs don't gain the privileges of a later enable
+	assert.NoError(t, c.Run(context.Background(), "every 10ms status"))
+	assert.NoError(t, c.Run(context.Background(), "every 10ms reboot"))
+	assert.NoError(t, c.Run(context.Background(), "enable"))
+	n := runs.Load()
+	assert.Eventually(t, func() bool { return runs.Load() >= n+2 }, time.Second, time.Millisecond)
+	assert.Zero(t, privileged.Load())
+	assert.True(t, c.Elevated())
+	mu.Lock()
+	assert.Contains(t, out.String(), "[job#2] ")
+	assert.Contains(t, out.String(), console.ErrPermissionDenied.Error())
+	mu.Unlock()
+
+	// scheduled elevated, they keep the privileges
+	assert.NoError(t, c.CancelJob(1))
+	assert.NoError(t, c.Run(context.Background(), "every 10ms status"))
+	assert.Eventually(t, func() bool { return privileged.Load() >= 1 }, time.Second, time.Millisecond)
+}
+
+func TestResult(t *testing.T) {
+	type user struct {
+		Name  string `json:"name"`
+		Admin bool   `json:"admin"`
+	}
+	users := []user{{"alice", true}, {"bob", false}}
+	cmds := []*console.Cmd{
+		{
+			Name: "users",
+			ResultHandler: func(ctx context.Context, c *console.Console, args []string) (*console.Result, error) {
+				return console.NewResult(users), nil
+			},
+		},
+		{
+			Name: "count",
+			ResultHandler: func(ctx context.Context, c *console.Console, args []string) (*console.Result, error) {
+				return console.NewResult(len(users)).With(console.FormatTable, func(w io.Writer, data any) error {
+					_, err := fmt.Fprintf(w, "%d users\n", data)
+					return err
+				}), nil
+			},
+		},
+	}
+
+	for _, tt := range []struct {
+		format console.Format
+		line   string
+		want   string
+	}{
+		{console.FormatTable, "users", "Name   Admin\nalice  true\nbob    false\n"},
+		{console.FormatText, "users", "{alice true}\n{bob false}\n"},
+		{console.FormatJSON, "users", "[\n  {\n    \"name\": \"alice\",\n    \"admin\": true\n  },\n  {\n    \"name\": \"bob\",\n    \"admin\": false\n  }\n]\n"},
+		{console.FormatTable, "count", "2 users\n"},
+		{console.FormatJSON, "count", "2\n"},
+	} {
+		var out bytes.Buffer
+		c, err := console.New(console.WithOutput(&out), console.WithOutputFormat(tt.format))
+		assert.NoError(t, err)
+		assert.NoError(t, c.RegisterCommands(cmds...))
+		assert.NoError(t, c.Run(context.Background(), tt.line))
+		assert.Equal(t, tt.want, out.String(), "%s %s", tt.format, tt.line)
+		v, _ := c.Get(console.ResultKey)
+		assert.NotNil(t, v)
+		c.Close()
+	}
+
+	_, err := console.New(console.WithOutputFormat("xml"))
+	assert.Error(t, err)
+}
+
+func TestOutputFormat(t *testing.T) {
+	var out bytes.Buffer
+	c, err := console.New(console.WithOutput(&out))
+	assert.NoError(t, err)
+	defer c.Close()
+	var formats []console.Format
+	var gotArgs []string
+	err = c.RegisterCommands(&console.Cmd{
+		Name: "get",
+		ResultHandler: func(ctx context.Context, c *console.Console, args []string) (*console.Result, error) {
+			formats = append(formats, c.OutputFormat(ctx))
+			gotArgs = args
+			return console.NewResult(map[string]int{"a": 1}), nil
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.Run(context.Background(), "get x --output yaml"))
+	assert.Equal(t, "a: 1\n", out.String())
+	assert.Equal(t, []string{"x"}, gotArgs)
+
+	out.Reset()
+	assert.NoError(t, c.Run(context.Background(), "get --output=json -- --output"))
+	assert.Equal(t, "{\n  \"a\": 1\n}\n", out.String())
+	assert.Equal(t, []string{"--", "--output"}, gotArgs)
+
+	assert.Error(t, c.Run(context.Background(), "get --output xml"))
+	assert.Error(t, c.Run(context.Background(), "get --output"))
+
+	// commands with their own --output get the flag
+	type saveParams struct {
+		Output string `flag:"output"`
+	}
+	var saved string
+	assert.NoError(t, c.RegisterCommands(console.Bind(&console.Cmd{Name: "save"}, func(c *console.Console, p *saveParams) error {
+		saved = p.Output
+		return nil
+	})))
+	assert.NoError(t, c.Run(context.Background(), "save --output out.txt"))
+	assert.Equal(t, "out.txt", saved)
+
+	out.Reset()
+	assert.NoError(t, c.Run(context.Background(), "format text"))
+	assert.NoError(t, c.Run(context.Background(), "format"))
+	assert.NoError(t, c.Run(context.Background(), "get"))
+	assert.Equal(t, "text\nmap[a:1]\n", out.String())
+	assert.Equal(t, []console.Format{console.FormatYAML, console.FormatJSON, console.FormatText}, formats)
+	assert.Error(t, c.Run(context.Background(), "format xml"))
+	assert.Equal(t, []string{"format table", "format text"}, c.Complete("format t"))
+}
+
+func TestApropos(t *testing.T) {
+	var out bytes.Buffer
+	c, err := console.New(console.WithOutput(&out), console.WithoutDefaultCmds())
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.RegisterCommands(
+		&console.Cmd{Name: "deploy", Aliases: []string{"ship"}, Description: "Roll out a release"},
+		&console.Cmd{Name: "rollback", Description: "Revert a release", Args: []*console.Arg{{Name: "version"}}},
+		&console.Cmd{Name: "users", Description: "List accounts", Group: "Admin"},
+	))
+	assert.NoError(t, c.RegisterNamespace("db", &console.Cmd{Name: "backup", Description: "Dump the database"}))
+	assert.NoError(t, c.RegisterLazy("report", "Generate a report", func() *console.Cmd {
+		t.Fatal("searching loaded the command")
+		return nil
+	}))
+
+	assert.Equal(t, "  deploy - Roll out a release\n  rollback - Revert a release", c.Apropos("RELEASE"))
+	assert.Equal(t, "  deploy - Roll out a release", c.Apropos("ship"))
+	assert.Equal(t, "  rollback - Revert a release", c.Apropos("version"))
+	assert.Equal(t, "  users - List accounts", c.Apropos("admin"))
+	assert.Equal(t, "  db backup - Dump the database", c.Apropos("database"))
+	assert.Equal(t, "  quit - Exit the console", c.Apropos("exit"))
+	assert.Equal(t, `No commands matching "nothing"`, c.Apropos("nothing"))
+
+	c, err = console.New(console.WithOutput(&out))
+	assert.NoError(t, err)
+	defer c.Close()
+	out.Reset()
+	assert.NoError(t, c.Run(context.Background(), "help -k screen"))
+	assert.Equal(t, "  clear - Clear the screen\n", out.String())
+	assert.Error(t, c.Run(context.Background(), "help -k"))
+}
+
+func TestManPage(t *testing.T) {
+	var out bytes.Buffer
+	c, err := console.New(console.WithOutput(&out))
+	assert.NoError(t, err)
+	defer c.Close()
+	type params struct {
+		Env    string `flag:"env" help:"Target environment" default:"staging"`
+		Target string `arg:"target" required:"true"`
+	}
+	assert.NoError(t, c.RegisterCommands(
+		console.Bind(&console.Cmd{
+			Name:        "deploy",
+			Aliases:     []string{"ship"},
+			Description: "Deploy a service",
+			LongHelp:    "Builds the service and rolls it out.\nRunning deployments are replaced.",
+			Examples:    []string{"deploy web --env prod  # deploy to production"},
+			SeeAlso:     []string{"rollback"},
+		}, func(c *console.Console, p *params) error { return nil }),
+		&console.Cmd{Name: "rollback", Description: "Revert a deployment", Args: []*console.Arg{{Name: "version", Values: []string{"v1", "v2"}}}},
+	))
+	assert.NoError(t, c.RegisterNamespace("db", &console.Cmd{Name: "backup", Description: "Dump the database"}))
+
+	page, err := c.RenderManPage("ship")
+	assert.NoError(t, err)
+	assert.Equal(t, `NAME
+    deploy - Deploy a service
+
+ALIASES
+    ship
+
+USAGE
+    Usage: deploy [flags] <target>
+
+    Arguments:
+      target               (required)
+
+    Flags:
+          --env string     Target environment (default staging)
+
+DESCRIPTION
+    Builds the service and rolls it out.
+    Running deployments are replaced.
+
+EXAMPLES
+    deploy web --env prod  # deploy to production
+
+SEE ALSO
+    rollback`, page)
+
+	page, err = c.RenderManPage("rollback")
+	assert.NoError(t, err)
+	assert.Contains(t, page, "Usage: rollback [<version>]\n\n    Arguments:\n      version              (one of v1, v2)")
+
+	page, err = c.RenderManPage("db", "backup")
+	assert.NoError(t, err)
+	assert.Contains(t, page, "db backup - Dump the database")
+	page, err = c.RenderManPage("db")
+	assert.NoError(t, err)
+	assert.Contains(t, page, "Commands of db:")
+	_, err = c.RenderManPage("nope")
+	assert.ErrorIs(t, err, console.ErrCmdNotFound)
+
+	assert.NoError(t, c.Run(context.Background(), "man quit"))
+	assert.Contains(t, out.String(), "quit - Exit the console")
+	assert.Equal(t, "  deploy - Deploy a service", c.Apropos("rolls it out"))
+	assert.Equal(t, []string{"help rollback"}, c.Complete("help ro"))
+	assert.Equal(t, []string{"help db backup"}, c.Complete("help db b"))
+}
+
+func TestPager(t *testing.T) {
+	var out bytes.Buffer
+	tr := console.NewTerminalReader(readWriter{strings.NewReader("\rq\r"), io.Discard})
+	assert.NoError(t, tr.SetSize(80, 4))
+	c, err := console.New(console.WithLineReader(tr), console.WithOutput(&out))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	lines := make([]string, 10)
+	for i := range lines {
+		lines[i] = strconv.Itoa(i)
+	}
+	assert.NoError(t, c.Page(strings.Join(lines, "\n")))
+	assert.Equal(t, "0\n1\n2\n3\n4\n5\n", out.String())
+
+	c, err = console.New(console.WithLineReader(tr), console.WithOutput(&out), console.WithPager(false))
+	assert.NoError(t, err)
+	defer c.Close()
+	out.Reset()
+	assert.NoError(t, c.Page(strings.Join(lines, "\n")))
+	assert.Equal(t, strings.Join(lines, "\n")+"\n", out.String())
+}
+
+func TestDryRun(t *testing.T) {
+	var out bytes.Buffer
+	c, err := console.New(console.WithOutput(&out), console.WithDryRun(), console.WithExec(), console.WithPrompt("> "))
+	assert.NoError(t, err)
+	defer c.Close()
+	var dropped, planned []string
+	assert.NoError(t, c.RegisterCommands(
+		&console.Cmd{
+			Name:   "drop",
+			Danger: console.DangerHigh,
+			Handler: func(c *console.Console, args []string) error {
+				dropped = append(dropped, args...)
+				return nil
+			},
+		},
+		&console.Cmd{
+			Name:           "purge",
+			Danger:         console.DangerCritical,
+			SupportsDryRun: true,
+			Handler: func(c *console.Console, args []string) error {
+				if c.DryRun() {
+					planned = append(planned, args...)
+					return nil
+				}
+				dropped = append(dropped, args...)
+				return nil
+			},
+		},
+	))
+
+	assert.NoError(t, c.Run(context.Background(), "dry-run on"))
+	assert.True(t, c.DryRun())
+	assert.Equal(t, "(dry-run) > ", c.Prompt())
+	assert.ErrorIs(t, c.Run(context.Background(), "drop users"), console.ErrDryRun)
+	assert.NoError(t, c.Run(context.Background(), "purge cache"))
+	assert.Empty(t, dropped)
+	assert.Equal(t, []string{"cache"}, planned)
+
+	code, err := c.Exec(context.Background(), "rm", "-rf", "my dir")
+	assert.NoError(t, err)
+	assert.Zero(t, code)
+	assert.Equal(t, "dry-run: would run rm -rf \"my dir\"\n", out.String())
+
+	child, err := c.NewChild()
+	assert.NoError(t, err)
+	assert.True(t, child.DryRun())
+
+	out.Reset()
+	assert.NoError(t, c.Run(context.Background(), "dry-run"))
+	assert.Equal(t, "dry-run is on\n", out.String())
+	assert.NoError(t, c.Run(context.Background(), "dry-run off"))
+	assert.Equal(t, "> ", c.Prompt())
+	assert.EqualError(t, c.Run(context.Background(), "dry-run maybe"), "usage: dry-run [on|off]")
+}
+
+func TestCatalog(t *testing.T) {
+	var out bytes.Buffer
+	c, err := console.New(console.WithOutput(&out), console.WithoutDefaultCmds())
+	assert.NoError(t, err)
+	defer c.Close()
+	type params struct {
+		Env    string `flag:"env" short:"e" help:"Target environment" default:"staging" enum:"staging,prod"`
+		Target string `arg:"target" required:"true"`
+	}
+	assert.NoError(t, c.RegisterCommands(
+		console.Bind(&console.Cmd{Name: "deploy", Aliases: []string{"ship"}, Description: "Deploy a service", Danger: console.DangerHigh},
+			func(c *console.Console, p *params) error { return nil }),
+		&console.Cmd{Name: "secret", Description: "Hidden", Permission: func(*console.Console) bool { return false }},
+	))
+	assert.NoError(t, c.RegisterNamespace("db", &console.Cmd{Name: "backup", Description: "Dump the database", Args: []*console.Arg{{Name: "table"}}}))
+
+	cat := c.Catalog()
+	if assert.Len(t, cat, 4) {
+		assert.Equal(t, console.CatalogCmd{
+			Name:        "deploy",
+			Aliases:     []string{"ship"},
+			Description: "Deploy a service",
+			Args:        []console.CatalogArg{{Name: "target", Required: true}},
+			Flags: []console.CatalogFlag{{
+				Name: "env", Short: "e", Type: "string", Description: "Target environment", Default: "staging", Values: []string{"staging", "prod"},
+			}},
+			Danger:    "high",
+			Permitted: true,
+		}, cat[0])
+		assert.True(t, cat[1].Restricted)
+		assert.False(t, cat[1].Permitted)
+		assert.Equal(t, []console.CatalogArg{{Name: "table"}}, cat[2].Commands[0].Args)
+		assert.Equal(t, "quit", cat[3].Name)
+		assert.True(t, cat[3].Builtin)
+	}
+
+	c, err = console.New(console.WithOutput(&out))
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.RegisterCommands(&console.Cmd{Name: "secret", Permission: func(*console.Console) bool { return false }}))
+	out.Reset()
+	assert.NoError(t, c.Run(context.Background(), "commands --json"))
+	assert.EqualError(t, c.Run(context.Background(), "commands --yaml"), "usage: commands [--json]")
+	var got []console.CatalogCmd
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &got))
+	assert.NotEmpty(t, got)
+	for _, cmd := range got {
+		assert.NotEqual(t, "secret", cmd.Name)
+	}
+	out.Reset()
+	assert.NoError(t, c.Run(context.Background(), "commands"))
+	assert.Contains(t, out.String(), "help (man)   Show the help\n")
+}
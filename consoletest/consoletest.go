@@ -0,0 +1,327 @@
+// Package consoletest runs a console against scripted input and captures
+// its output, so commands can be tested without a terminal.
+//
+//	h := consoletest.New(t)
+//	h.RegisterCommands(greetCmd)
+//	h.Send("greet bob").ExpectOutput("hello bob").ExpectNoError()
+//	h.Send("greet").ExpectError("missing name")
+//
+// Features only working on a terminal, like tab completion or ctrl-c, are
+// tested by running the console on a pseudo-terminal with StartHelper.
+package consoletest
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jon4hz/console"
+)
+
+// DefaultTimeout is the default of Harness.Timeout.
+const DefaultTimeout = 5 * time.Second
+
+// Harness is a console reading scripted input lines. The console is started
+// with the first line sent and closed once the test finishes.
+type Harness struct {
+	*console.Console
+
+	// Timeout is how long Send waits for the console to prompt again.
+	Timeout time.Duration
+
+	t      testing.TB
+	reader *scriptReader
+	out    buffer
+
+	mu     sync.Mutex
+	errs   []error
+	prompt string
+
+	started  bool
+	done     chan struct{}
+	startErr error
+}
+
+// New creates a console with the options and a harness feeding it.
+// The history file is disabled and the output is captured.
+func New(t testing.TB, opts ...console.Opts) *Harness {
+	t.Helper()
+	h := &Harness{
+		Timeout: DefaultTimeout,
+		t:       t,
+		reader: &scriptReader{
+			in:    make(chan string),
+			ready: make(chan string),
+			quit:  make(chan struct{}),
+		},
+		done: make(chan struct{}),
+	}
+	c, err := console.New(append(opts,
+		console.WithLineReader(h.reader),
+		console.WithOutput(&h.out),
+		console.WithHistoryFile(""),
+		console.WithHook(console.EventError, h.recordErr),
+	)...)
+	if err != nil {
+		t.Fatalf("consoletest: error creating console: %s", err)
+	}
+	h.Console = c
+	t.Cleanup(h.close)
+	return h
+}
+
+func (h *Harness) recordErr(e console.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.errs = append(h.errs, e.Err)
+}
+
+// Send enters a line and waits until the console prompts again, either for
+// the next command or for an input read by the command, like a password.
+func (h *Harness) Send(line string) *Step {
+	h.t.Helper()
+	h.start()
+	s := &Step{t: h.t, Input: line}
+	from, errs := h.mark()
+	select {
+	case h.reader.in <- line:
+	case <-h.done:
+		h.t.Fatalf("consoletest: can't send %q, the console exited", line)
+		return s
+	}
+	s.Exited = !h.wait(line)
+	s.Output = h.out.from(from)
+	h.mu.Lock()
+	s.Errs = append(s.Errs, h.errs[errs:]...)
+	s.Prompt = h.prompt
+	h.mu.Unlock()
+	return s
+}
+
+// Run sends the lines one after another. The returned step combines them.
+func (h *Harness) Run(lines ...string) *Step {
+	h.t.Helper()
+	all := &Step{t: h.t, Input: strings.Join(lines, "\n")}
+	for _, l := range lines {
+		s := h.Send(l)
+		all.Output += s.Output
+		all.Errs = append(all.Errs, s.Errs...)
+		all.Prompt, all.Exited = s.Prompt, s.Exited
+		if s.Exited {
+			break
+		}
+	}
+	return all
+}
+
+// Output returns the whole output of the console, including the welcome message.
+func (h *Harness) Output() string {
+	return h.out.from(0)
+}
+
+// Complete returns the completion candidates for line like pressing tab.
+func (h *Harness) Complete(line string) []string {
+	h.reader.mu.Lock()
+	f := h.reader.completer
+	h.reader.mu.Unlock()
+	if f == nil {
+		return nil
+	}
+	return f(line)
+}
+
+// Prompt returns the prompt the console is currently waiting at.
+func (h *Harness) Prompt() string {
+	h.t.Helper()
+	h.start()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.prompt
+}
+
+// ExpectPrompt fails the test if the console isn't waiting at the prompt want.
+func (h *Harness) ExpectPrompt(want string) {
+	h.t.Helper()
+	if got := h.Prompt(); got != want {
+		h.t.Errorf("consoletest: prompt is %q, want %q", got, want)
+	}
+}
+
+// start starts the console and waits for the first prompt.
+func (h *Harness) start() {
+	h.t.Helper()
+	if h.started {
+		return
+	}
+	h.started = true
+	go func() {
+		defer close(h.done)
+		h.startErr = h.Console.Start()
+	}()
+	if !h.wait("") {
+		h.t.Fatalf("consoletest: console exited before prompting: %v", h.startErr)
+	}
+}
+
+// wait waits for the next prompt and reports false if the console exited instead.
+func (h *Harness) wait(line string) bool {
+	h.t.Helper()
+	select {
+	case p := <-h.reader.ready:
+		h.mu.Lock()
+		h.prompt = p
+		h.mu.Unlock()
+		return true
+	case <-h.done:
+		h.mu.Lock()
+		h.prompt = ""
+		h.mu.Unlock()
+		return false
+	case <-time.After(h.Timeout):
+		h.t.Fatalf("consoletest: no prompt within %s after %q", h.Timeout, line)
+		return false
+	}
+}
+
+func (h *Harness) mark() (int, int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.out.len(), len(h.errs)
+}
+
+func (h *Harness) close() {
+	close(h.reader.quit)
+	h.Console.Close()
+	if h.started {
+		<-h.done
+	}
+}
+
+// Step is the result of input sent to the console.
+type Step struct {
+	t testing.TB
+
+	Input string
+	// Output is everything the console printed until it prompted again.
+	Output string
+	// Errs are the errors of failed or refused commands.
+	Errs []error
+	// Prompt is shown after the input. It's empty if the console exited.
+	Prompt string
+	Exited bool
+}
+
+// ExpectOutput fails the test if the output doesn't contain want.
+func (s *Step) ExpectOutput(want string) *Step {
+	s.t.Helper()
+	if !strings.Contains(s.Output, want) {
+		s.t.Errorf("consoletest: output of %q doesn't contain %q:\n%s", s.Input, want, s.Output)
+	}
+	return s
+}
+
+// ExpectError fails the test if no command failed with an error containing want.
+// An empty want matches any error.
+func (s *Step) ExpectError(want string) *Step {
+	s.t.Helper()
+	for _, err := range s.Errs {
+		if strings.Contains(err.Error(), want) {
+			return s
+		}
+	}
+	s.t.Errorf("consoletest: %q didn't fail with %q, errors: %v", s.Input, want, s.Errs)
+	return s
+}
+
+// ExpectNoError fails the test if a command failed.
+func (s *Step) ExpectNoError() *Step {
+	s.t.Helper()
+	if len(s.Errs) > 0 {
+		s.t.Errorf("consoletest: %q failed: %v", s.Input, s.Errs)
+	}
+	return s
+}
+
+// ExpectPrompt fails the test if the console doesn't prompt with want after the input.
+func (s *Step) ExpectPrompt(want string) *Step {
+	s.t.Helper()
+	if s.Prompt != want {
+		s.t.Errorf("consoletest: prompt after %q is %q, want %q", s.Input, s.Prompt, want)
+	}
+	return s
+}
+
+// ExpectExit fails the test if the console didn't exit after the input.
+func (s *Step) ExpectExit() *Step {
+	s.t.Helper()
+	if !s.Exited {
+		s.t.Errorf("consoletest: console didn't exit after %q", s.Input)
+	}
+	return s
+}
+
+// scriptReader is a console.LineReader handing out the lines sent by the harness.
+type scriptReader struct {
+	in    chan string
+	ready chan string
+	quit  chan struct{}
+
+	mu        sync.Mutex
+	completer func(line string) []string
+}
+
+func (r *scriptReader) Prompt(prompt string) (string, error) {
+	select {
+	case r.ready <- prompt:
+	case <-r.quit:
+		return "", io.EOF
+	}
+	select {
+	case line := <-r.in:
+		return line, nil
+	case <-r.quit:
+		return "", io.EOF
+	}
+}
+
+func (r *scriptReader) PasswordPrompt(prompt string) (string, error) {
+	return r.Prompt(prompt)
+}
+
+func (r *scriptReader) SetCompleter(f func(line string) []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.completer = f
+}
+
+func (r *scriptReader) AppendHistory(item string)           {}
+func (r *scriptReader) ReadHistory(io.Reader) (int, error)  { return 0, nil }
+func (r *scriptReader) WriteHistory(io.Writer) (int, error) { return 0, nil }
+func (r *scriptReader) Close() error                        { return nil }
+
+// buffer is a bytes.Buffer safe for commands writing concurrently.
+type buffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *buffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *buffer) len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Len()
+}
+
+func (b *buffer) from(i int) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf.Bytes()[i:])
+}
@@ -0,0 +1,49 @@
+package consoletest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jon4hz/console"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHarness(t *testing.T) {
+	h := New(t, console.WithWelcomeMsg("welcome"), console.WithPrompt("test> "))
+	err := h.RegisterCommands(&console.Cmd{
+		Name: "greet",
+		Args: []*console.Arg{{Name: "name", Values: []string{"alice", "bob"}}},
+		Handler: func(c *console.Console, args []string) error {
+			if len(args) == 0 {
+				return errors.New("missing name")
+			}
+			c.Printf("hello %s\n", args[0])
+			return nil
+		},
+	}, &console.Cmd{
+		Name: "ask",
+		Handler: func(c *console.Console, args []string) error {
+			answer, err := c.ReadLine("answer? ")
+			if err != nil {
+				return err
+			}
+			c.Println("got", answer)
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+
+	h.ExpectPrompt("test> ")
+	assert.Contains(t, h.Output(), "welcome")
+	assert.Equal(t, []string{"greet bob"}, h.Complete("greet b"))
+
+	s := h.Send("greet bob").ExpectOutput("hello bob").ExpectNoError().ExpectPrompt("test> ")
+	assert.Equal(t, "hello bob\n", s.Output)
+	h.Send("greet").ExpectError("missing name")
+
+	h.Send("ask").ExpectPrompt("answer? ")
+	h.Send("42").ExpectOutput("got 42").ExpectPrompt("test> ")
+
+	s = h.Run("greet alice", "quit", "greet bob").ExpectOutput("hello alice").ExpectExit()
+	assert.NotContains(t, s.Output, "hello bob")
+}
@@ -0,0 +1,209 @@
+//go:build !windows
+
+package consoletest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+// Keys sent by Term.Send.
+const (
+	KeyEnter = "\r"
+	KeyTab   = "\t"
+	KeyCtrlC = "\x03"
+	KeyCtrlD = "\x04"
+	KeyUp    = "\x1b[A"
+	KeyDown  = "\x1b[B"
+)
+
+const helperEnv = "CONSOLETEST_HELPER"
+
+// Main runs the helper named by StartHelper if the test binary was started
+// by it, or the tests otherwise. Call it from TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		consoletest.Main(m, map[string]func(){"greet": runGreetConsole})
+//	}
+func Main(m *testing.M, helpers map[string]func()) {
+	name := os.Getenv(helperEnv)
+	if name == "" {
+		os.Exit(m.Run())
+	}
+	fn, ok := helpers[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "consoletest: unknown helper %q\n", name)
+		os.Exit(2)
+	}
+	fn()
+	os.Exit(0)
+}
+
+// StartHelper re-runs the test binary on a pseudo-terminal, which calls
+// the helper name registered with Main instead of running the tests.
+func StartHelper(t testing.TB, name string) *Term {
+	t.Helper()
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), helperEnv+"="+name)
+	return Start(t, cmd)
+}
+
+// Term is a process running on a pseudo-terminal. Keys are sent to it
+// like typed by a user and its output is matched with ExpectRegex.
+type Term struct {
+	t   testing.TB
+	cmd *exec.Cmd
+	pty *os.File
+
+	mu      sync.Mutex
+	buf     []byte
+	readErr error
+	more    chan struct{}
+
+	done    chan struct{}
+	waitErr error
+}
+
+// Start starts cmd on a pseudo-terminal of 80x24 characters.
+// The process is killed once the test finishes.
+func Start(t testing.TB, cmd *exec.Cmd) *Term {
+	t.Helper()
+	f, err := pty.StartWithSize(cmd, &pty.Winsize{Cols: 80, Rows: 24})
+	if err != nil {
+		t.Fatalf("consoletest: error starting %s on a pty: %s", cmd.Path, err)
+	}
+	term := &Term{
+		t:    t,
+		cmd:  cmd,
+		pty:  f,
+		more: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	go term.read()
+	go func() {
+		term.waitErr = cmd.Wait()
+		close(term.done)
+	}()
+	t.Cleanup(term.close)
+	return term
+}
+
+func (t *Term) read() {
+	b := make([]byte, 4096)
+	for {
+		n, err := t.pty.Read(b)
+		t.mu.Lock()
+		t.buf = append(t.buf, b[:n]...)
+		if err != nil {
+			t.readErr = err
+		}
+		t.mu.Unlock()
+		select {
+		case t.more <- struct{}{}:
+		default:
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Send writes keys to the terminal, see the Key constants.
+func (t *Term) Send(keys string) {
+	t.t.Helper()
+	if _, err := io.WriteString(t.pty, keys); err != nil {
+		t.t.Fatalf("consoletest: error sending %q: %s", keys, err)
+	}
+}
+
+// SendLine sends line followed by enter.
+func (t *Term) SendLine(line string) {
+	t.t.Helper()
+	t.Send(line + KeyEnter)
+}
+
+// ExpectRegex waits until the output matches pattern and returns the match
+// and its submatches. The output up to the end of the match is consumed, so
+// the next call only matches output following it. The test fails if the
+// output doesn't match within timeout.
+func (t *Term) ExpectRegex(pattern string, timeout time.Duration) []string {
+	t.t.Helper()
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		t.t.Fatalf("consoletest: invalid pattern: %s", err)
+	}
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		t.mu.Lock()
+		loc := re.FindSubmatchIndex(t.buf)
+		if loc != nil {
+			m := make([]string, len(loc)/2)
+			for i := range m {
+				if loc[2*i] >= 0 {
+					m[i] = string(t.buf[loc[2*i]:loc[2*i+1]])
+				}
+			}
+			t.buf = t.buf[loc[1]:]
+			t.mu.Unlock()
+			return m
+		}
+		out, readErr := string(t.buf), t.readErr
+		t.mu.Unlock()
+		if readErr != nil {
+			t.t.Fatalf("consoletest: output ended (%s) without matching %q:\n%q", readErr, pattern, out)
+		}
+		select {
+		case <-t.more:
+		case <-deadline.C:
+			t.t.Fatalf("consoletest: output didn't match %q within %s:\n%q", pattern, timeout, out)
+		}
+	}
+}
+
+// Expect waits until the output contains s, see ExpectRegex.
+func (t *Term) Expect(s string, timeout time.Duration) {
+	t.t.Helper()
+	t.ExpectRegex(regexp.QuoteMeta(s), timeout)
+}
+
+// Resize changes the size of the terminal.
+func (t *Term) Resize(cols, rows uint16) {
+	t.t.Helper()
+	if err := pty.Setsize(t.pty, &pty.Winsize{Cols: cols, Rows: rows}); err != nil {
+		t.t.Fatalf("consoletest: error resizing the pty: %s", err)
+	}
+}
+
+// Wait waits for the process to exit and returns its error.
+func (t *Term) Wait(timeout time.Duration) error {
+	t.t.Helper()
+	select {
+	case <-t.done:
+		return t.waitErr
+	case <-time.After(timeout):
+		t.t.Fatalf("consoletest: process didn't exit within %s", timeout)
+		return nil
+	}
+}
+
+func (t *Term) close() {
+	select {
+	case <-t.done:
+	default:
+		if err := t.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			t.t.Logf("consoletest: error killing process: %s", err)
+		}
+		<-t.done
+	}
+	t.pty.Close()
+}
@@ -0,0 +1,49 @@
+//go:build !windows
+
+package consoletest
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jon4hz/console"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	Main(m, map[string]func(){"greet": runGreetConsole})
+}
+
+func runGreetConsole() {
+	c, err := console.New(console.WithPrompt("pty> "), console.WithHistoryFile(""))
+	if err != nil {
+		os.Exit(1)
+	}
+	defer c.Close()
+	_ = c.RegisterCommands(&console.Cmd{
+		Name: "greet",
+		Args: []*console.Arg{{Name: "name", Values: []string{"alice", "bob"}}},
+		Handler: func(c *console.Console, args []string) error {
+			c.Printf("hello %s\n", args[0])
+			return nil
+		},
+	})
+	_ = c.Start()
+}
+
+func TestTerm(t *testing.T) {
+	term := StartHelper(t, "greet")
+	term.Expect("pty> ", 5*time.Second)
+
+	term.Send("gre" + KeyTab)
+	term.Expect("greet", time.Second)
+	term.Send(" b" + KeyTab + KeyEnter)
+	m := term.ExpectRegex(`hello (\w+)`, time.Second)
+	assert.Equal(t, "bob", m[1])
+
+	term.Expect("pty> ", time.Second)
+	term.Send(KeyCtrlC)
+	term.Expect("Aborted", time.Second)
+	assert.NoError(t, term.Wait(5*time.Second))
+}
@@ -0,0 +1,104 @@
+package console
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrNotConfirmed = errors.New("command not confirmed")
+
+// DangerLevel describes how destructive a command is.
+type DangerLevel int
+
+const (
+	DangerNone DangerLevel = iota
+	DangerLow
+	DangerHigh
+	DangerCritical
+)
+
+func (d DangerLevel) String() string {
+	switch d {
+	case DangerNone:
+		return "none"
+	case DangerLow:
+		return "low"
+	case DangerHigh:
+		return "high"
+	case DangerCritical:
+		return "critical"
+	}
+	return fmt.Sprintf("DangerLevel(%d)", int(d))
+}
+
+// ConfirmPolicy describes how the user has to confirm a dangerous command.
+type ConfirmPolicy int
+
+const (
+	// ConfirmNone runs the command without confirmation.
+	ConfirmNone ConfirmPolicy = iota
+	// ConfirmYesNo asks the user to answer with yes.
+	ConfirmYesNo
+	// ConfirmName asks the user to retype the name of the command.
+	ConfirmName
+	// ConfirmTarget asks the user to retype the first argument of the command, e.g. the name of a resource.
+	// Commands without arguments fall back to ConfirmName.
+	ConfirmTarget
+)
+
+var defaultConfirmPolicies = map[DangerLevel]ConfirmPolicy{
+	DangerLow:      ConfirmYesNo,
+	DangerHigh:     ConfirmName,
+	DangerCritical: ConfirmTarget,
+}
+
+// WithConfirmPolicy sets the confirmation policy for commands of the given danger level.
+func WithConfirmPolicy(level DangerLevel, policy ConfirmPolicy) Opts {
+	return func(c *Console) {
+		if c.confirmPolicies == nil {
+			c.confirmPolicies = make(map[DangerLevel]ConfirmPolicy, len(defaultConfirmPolicies))
+			for l, p := range defaultConfirmPolicies {
+				c.confirmPolicies[l] = p
+			}
+		}
+		c.confirmPolicies[level] = policy
+	}
+}
+
+func (c *Console) confirmPolicy(level DangerLevel) ConfirmPolicy {
+	if c.confirmPolicies == nil {
+		return defaultConfirmPolicies[level]
+	}
+	return c.confirmPolicies[level]
+}
+
+// confirm asks the user to confirm a dangerous command.
+func (c *Console) confirm(cmd *Cmd, args []string) error {
+	policy := c.confirmPolicy(cmd.Danger)
+	if policy == ConfirmTarget && len(args) == 0 {
+		policy = ConfirmName
+	}
+
+	var prompt, want string
+	switch policy {
+	case ConfirmNone:
+		return nil
+	case ConfirmYesNo:
+		prompt, want = c.msg(MsgConfirmYesNo, cmd.Name), c.msg(MsgConfirmYes)
+	case ConfirmName:
+		prompt, want = c.msg(MsgConfirmType, cmd.Name), cmd.Name
+	case ConfirmTarget:
+		prompt, want = c.msg(MsgConfirmType, args[0]), args[0]
+	}
+
+	c.Println(c.theme.Error.Render(c.msg(MsgDangerousCmd, cmd.Name, cmd.Danger)))
+	in, err := c.ReadLine(prompt)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(in) != want {
+		return ErrNotConfirmed
+	}
+	return nil
+}
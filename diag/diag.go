@@ -0,0 +1,186 @@
+// Package diag provides a debug command with diagnostics of the host process.
+//
+//	c.RegisterCommands(diag.Cmd(func(c *console.Console) bool {
+//		return c.Identity().HasRole("admin")
+//	}))
+package diag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	rpprof "runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/jon4hz/console"
+)
+
+const defaultPprofAddr = "localhost:6060"
+
+// Option configures the debug command.
+type Option func(*config)
+
+type config struct {
+	remotePprof bool
+}
+
+// WithRemotePprof allows pprof to listen on addresses other than loopback,
+// which exposes the profiles to the network.
+func WithRemotePprof() Option {
+	return func(cfg *config) {
+		cfg.remotePprof = true
+	}
+}
+
+// Cmd returns the debug command. As it reveals the internals of the process,
+// it's only permitted to users for which permission returns true, nobody if
+// it's nil, and has to be confirmed like commands of DangerHigh.
+func Cmd(permission func(c *console.Console) bool, opts ...Option) *console.Cmd {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if permission == nil {
+		permission = func(*console.Console) bool { return false }
+	}
+	return &console.Cmd{
+		Name:        "debug",
+		Description: "Show diagnostics of the process",
+		Args: []*console.Arg{{
+			Name:   "action",
+			Values: []string{"goroutines", "memstats", "gc", "pprof", "buildinfo"},
+		}},
+		Permission: permission,
+		Danger:     console.DangerHigh,
+		Handler: func(c *console.Console, args []string) error {
+			return handle(c, cfg, args)
+		},
+	}
+}
+
+func handle(c *console.Console, cfg config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: debug goroutines|memstats|gc|pprof on [addr]|pprof off|buildinfo")
+	}
+	switch args[0] {
+	case "goroutines":
+		return rpprof.Lookup("goroutine").WriteTo(c.Stdout(), 1)
+	case "memstats":
+		c.Println(memStats())
+		return nil
+	case "gc":
+		start := time.Now()
+		runtime.GC()
+		debug.FreeOSMemory()
+		c.Printf("GC done in %s\n", time.Since(start))
+		return nil
+	case "pprof":
+		return handlePprof(c, cfg, args[1:])
+	case "buildinfo":
+		bi, ok := debug.ReadBuildInfo()
+		if !ok {
+			return errors.New("no build info available")
+		}
+		c.Println(bi.String())
+		return nil
+	}
+	return fmt.Errorf("unknown action %q", args[0])
+}
+
+func memStats() string {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return fmt.Sprintf(`Goroutines:   %d
+Heap alloc:   %s
+Heap sys:     %s
+Heap objects: %d
+Total alloc:  %s
+Sys:          %s
+GC cycles:    %d
+Last GC:      %s
+GC pause:     %s`,
+		runtime.NumGoroutine(),
+		formatBytes(m.HeapAlloc),
+		formatBytes(m.HeapSys),
+		m.HeapObjects,
+		formatBytes(m.TotalAlloc),
+		formatBytes(m.Sys),
+		m.NumGC,
+		time.Unix(0, int64(m.LastGC)).Format(time.RFC3339),
+		time.Duration(m.PauseTotalNs),
+	)
+}
+
+func formatBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+// the pprof listener is process-wide, like the profiles
+var (
+	pprofMu  sync.Mutex
+	pprofSrv *http.Server
+)
+
+// handlePprof starts or stops the pprof listener.
+// It only listens on loopback addresses unless WithRemotePprof is set.
+func handlePprof(c *console.Console, cfg config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: debug pprof on [addr]|off")
+	}
+	pprofMu.Lock()
+	defer pprofMu.Unlock()
+
+	switch args[0] {
+	case "on":
+		if pprofSrv != nil {
+			return fmt.Errorf("pprof is already listening on %s", pprofSrv.Addr)
+		}
+		addr := defaultPprofAddr
+		if len(args) > 1 {
+			addr = args[1]
+		}
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return err
+		}
+		if ip := l.Addr().(*net.TCPAddr).IP; !ip.IsLoopback() && !cfg.remotePprof {
+			l.Close()
+			return fmt.Errorf("refusing to expose pprof on %s, use a loopback address", l.Addr())
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		pprofSrv = &http.Server{Addr: l.Addr().String(), Handler: mux}
+		go pprofSrv.Serve(l)
+		c.Printf("pprof listening on http://%s/debug/pprof/\n", pprofSrv.Addr)
+		return nil
+	case "off":
+		if pprofSrv == nil {
+			return errors.New("pprof is not listening")
+		}
+		ctx, cancel := context.WithTimeout(c.Ctx(), 5*time.Second)
+		defer cancel()
+		err := pprofSrv.Shutdown(ctx)
+		pprofSrv = nil
+		return err
+	}
+	return fmt.Errorf("unknown pprof action %q", args[0])
+}
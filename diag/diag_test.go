@@ -0,0 +1,50 @@
+package diag
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jon4hz/console"
+	"github.com/stretchr/testify/assert"
+)
+
+func newConsole(t *testing.T, out io.Writer) *console.Console {
+	c, err := console.New(console.WithOutput(out), console.WithHistoryFile(""))
+	assert.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestMemStats(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, handle(newConsole(t, &buf), config{}, []string{"memstats"}))
+	assert.Contains(t, buf.String(), "Heap alloc:")
+}
+
+func TestPprof(t *testing.T) {
+	var buf bytes.Buffer
+	c := newConsole(t, &buf)
+	assert.Error(t, handle(c, config{}, []string{"pprof", "on", ":0"}))
+	assert.NoError(t, handle(c, config{}, []string{"pprof", "on", "127.0.0.1:0"}))
+	defer handle(c, config{}, []string{"pprof", "off"})
+
+	url := strings.TrimSpace(strings.TrimPrefix(buf.String(), "pprof listening on "))
+	resp, err := http.Get(url)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestCmd(t *testing.T) {
+	c := newConsole(t, io.Discard)
+	assert.NoError(t, c.RegisterCommands(Cmd(nil)))
+	assert.ErrorIs(t, c.Run(context.Background(), "debug memstats"), console.ErrPermissionDenied)
+
+	cmd := Cmd(func(*console.Console) bool { return true })
+	assert.Equal(t, console.DangerHigh, cmd.Danger)
+	assert.True(t, cmd.Permission(c))
+}
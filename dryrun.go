@@ -0,0 +1,76 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrDryRun is returned for dangerous commands without dry-run support while dry-run is enabled.
+var ErrDryRun = errors.New("command doesn't support dry-run")
+
+// WithDryRun registers the dry-run builtin, which turns dry-run on and off, see SetDryRun.
+func WithDryRun() Opts {
+	return func(c *Console) {
+		c.dryRunCmd = true
+	}
+}
+
+// SetDryRun enables or disables dry-run. While enabled, commands with
+// SupportsDryRun are expected to describe what they would do instead of
+// doing it, and dangerous commands without it fail with ErrDryRun. Dangerous
+// commands aren't confirmed, as nothing happens. The prompt starts with
+// "(dry-run)".
+func (c *Console) SetDryRun(enabled bool) {
+	c.dryRun.Store(enabled)
+}
+
+// DryRun reports whether dry-run is enabled for the console or its parent.
+// Handlers of commands with SupportsDryRun must check it.
+func (c *Console) DryRun() bool {
+	return c.dryRun.Load() || (c.parent != nil && c.parent.DryRun())
+}
+
+// checkDryRun rejects cmd if it could change something during a dry-run.
+func (c *Console) checkDryRun(cmd *Cmd) error {
+	if cmd.Danger > DangerNone && !cmd.SupportsDryRun && c.DryRun() {
+		return fmt.Errorf("%w: %s", ErrDryRun, cmd.Name)
+	}
+	return nil
+}
+
+func (c *Console) dryRunPrompt(prompt string) string {
+	if !c.DryRun() {
+		return prompt
+	}
+	return "(dry-run) " + prompt
+}
+
+var dryRunCmd = &Cmd{
+	Name:        "dry-run",
+	Description: "Show or toggle dry-run",
+	descID:      MsgDryRunDescription,
+	builtin:     true,
+	Args:        []*Arg{{Name: "state", Values: []string{"off", "on"}}},
+	ContextHandler: func(ctx context.Context, c *Console, args []string) error {
+		if len(args) == 0 {
+			state := "off"
+			if c.DryRun() {
+				state = "on"
+			}
+			fmt.Fprintln(c.Writer(ctx), c.msg(MsgDryRunState, state))
+			return nil
+		}
+		if len(args) == 1 {
+			switch args[0] {
+			case "on":
+				c.SetDryRun(true)
+				return nil
+			case "off":
+				c.SetDryRun(false)
+				return nil
+			}
+		}
+		return errors.New(c.msg(MsgUsageError, "dry-run [on|off]"))
+	},
+}
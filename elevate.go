@@ -0,0 +1,188 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jon4hz/console/parse"
+)
+
+var ErrNotElevated = errors.New("privileges are not elevated")
+
+// Elevation configures the enable, disable and sudo commands.
+type Elevation struct {
+	// Role is granted while privileges are elevated.
+	Role string
+	// Timeout after which the elevated privileges expire.
+	// A zero timeout never expires.
+	Timeout time.Duration
+	// Verify checks the credentials entered by the user.
+	Verify func(c *Console, password string) error
+	// Prompt is shown while privileges are elevated.
+	// Defaults to the regular prompt prefixed with the role.
+	Prompt string
+}
+
+// WithElevation registers the enable, disable and sudo commands
+// which temporarily grant the user a higher role. Verify must be set.
+func WithElevation(e Elevation) Opts {
+	return func(c *Console) {
+		if e.Verify == nil {
+			c.optErr = errors.New("elevation requires a Verify function")
+			return
+		}
+		c.elevation = &e
+	}
+}
+
+// Elevated reports whether the privileges of the user are currently elevated.
+func (c *Console) Elevated() bool {
+	c.elevationMu.Lock()
+	defer c.elevationMu.Unlock()
+	if c.elevatedUntil.IsZero() {
+		return false
+	}
+	if !c.elevatedUntil.Equal(elevatedForever) && time.Now().After(c.elevatedUntil) {
+		c.elevatedUntil = time.Time{}
+		return false
+	}
+	return true
+}
+
+// elevatedForever is the expiry of an elevation without timeout.
+var elevatedForever = time.Unix(1<<62, 0)
+
+// Elevate prompts for the credentials and grants the elevated role.
+func (c *Console) Elevate() error {
+	if c.elevation == nil {
+		return errors.New("elevation is not configured")
+	}
+	if !c.Elevated() {
+		if err := c.verifyElevation(); err != nil {
+			return err
+		}
+	}
+	until := elevatedForever
+	if c.elevation.Timeout > 0 {
+		until = time.Now().Add(c.elevation.Timeout)
+	}
+	c.setElevatedUntil(until)
+	return nil
+}
+
+// verifyElevation prompts for the credentials and checks them.
+func (c *Console) verifyElevation() error {
+	password, err := c.ReadPassword(c.msg(MsgPassword))
+	if err != nil {
+		return err
+	}
+	if err := c.elevation.Verify(c, password); err != nil {
+		c.logger.Warn("elevation failed", "user", c.identity.Name, "err", err)
+		return fmt.Errorf("%w: %s", ErrUnauthenticated, err)
+	}
+	c.logger.Info("privileges elevated", "user", c.identity.Name, "role", c.elevation.Role)
+	return nil
+}
+
+// Drop drops elevated privileges.
+func (c *Console) Drop() {
+	c.setElevatedUntil(time.Time{})
+}
+
+// unelevated runs fn with the elevated privileges dropped and restores them afterwards.
+// No other command must run meanwhile, as it would see the privileges dropped too.
+func (c *Console) unelevated(fn func() error) error {
+	c.elevationMu.Lock()
+	until := c.elevatedUntil
+	c.elevatedUntil = time.Time{}
+	c.elevationMu.Unlock()
+	defer func() {
+		c.elevationMu.Lock()
+		defer c.elevationMu.Unlock()
+		if c.elevatedUntil.IsZero() {
+			c.elevatedUntil = until
+		}
+	}()
+	return fn()
+}
+
+func (c *Console) setElevatedUntil(t time.Time) {
+	c.elevationMu.Lock()
+	defer c.elevationMu.Unlock()
+	c.elevatedUntil = t
+}
+
+func (c *Console) currentPrompt() string {
+	return c.dryRunPrompt(c.elevatedPrompt())
+}
+
+func (c *Console) elevatedPrompt() string {
+	prompt := c.modePrompt(c.dirPrompt(c.prompt))
+	if c.elevation == nil || !c.Elevated() {
+		return prompt
+	}
+	if c.elevation.Prompt != "" {
+		return c.modePrompt(c.dirPrompt(c.elevation.Prompt))
+	}
+	return fmt.Sprintf("(%s) %s", c.elevation.Role, prompt)
+}
+
+var elevationCmds = []*Cmd{
+	enableCmd,
+	disableCmd,
+	sudoCmd,
+}
+
+var enableCmd = &Cmd{
+	Name:        "enable",
+	Description: "Elevate privileges",
+	descID:      MsgEnableDescription,
+	builtin:     true,
+	interactive: true,
+	IgnorePipe:  true,
+	Handler: func(c *Console, args []string) error {
+		return c.Elevate()
+	},
+}
+
+var disableCmd = &Cmd{
+	Name:        "disable",
+	Description: "Drop elevated privileges",
+	descID:      MsgDisableDescription,
+	builtin:     true,
+	Handler: func(c *Console, args []string) error {
+		if !c.Elevated() {
+			return ErrNotElevated
+		}
+		c.Drop()
+		return nil
+	},
+}
+
+var sudoCmd = &Cmd{
+	Name:        "sudo",
+	Description: "Run a command with elevated privileges",
+	descID:      MsgSudoDescription,
+	builtin:     true,
+	interactive: true,
+	// sudo only elevates the command it runs, like its namesake. As it's
+	// run serially, no other command runs while it's elevated.
+	Concurrency: ConcurrencySerial,
+	ContextHandler: func(ctx context.Context, c *Console, args []string) error {
+		if len(args) == 0 {
+			return errors.New(c.msg(MsgUsageError, "sudo <command> [args...]"))
+		}
+		line := parse.Join(args)
+		if c.Elevated() {
+			return c.Run(ctx, line)
+		}
+		if err := c.verifyElevation(); err != nil {
+			return err
+		}
+		c.setElevatedUntil(elevatedForever)
+		defer c.Drop()
+		return c.Run(ctx, line)
+	},
+}
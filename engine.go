@@ -0,0 +1,56 @@
+package console
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Engine holds the commands shared by many console sessions, e.g. of a server
+// serving a console per connection. Each session has its own history, prompt and
+// state, but all of them dispatch to the same registered commands.
+type Engine struct {
+	mu   sync.RWMutex
+	cmds []*Cmd
+	opts []Opts
+
+	// calls are the recent executions of rate limited commands, see allow.
+	callsMu sync.Mutex
+	calls   map[*Cmd][]time.Time
+}
+
+// NewEngine creates an engine. The options are applied to every session before the options of the session.
+func NewEngine(opts ...Opts) *Engine {
+	return &Engine{opts: opts}
+}
+
+// RegisterCommands registers commands available in all sessions.
+// It's safe to register commands while sessions are running.
+// Like Console.RegisterCommands, nothing is registered on error.
+func (e *Engine) RegisterCommands(cmds ...*Cmd) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	r := registration{existing: e.cmds}
+	for _, cmd := range cmds {
+		r.check(cmd)
+	}
+	if err := r.err(); err != nil {
+		return err
+	}
+	e.cmds = append(e.cmds, cmds...)
+	return nil
+}
+
+// NewSession creates a console session dispatching to the commands of the engine.
+// The environment of the server doesn't apply to sessions, see WithEnvPrefix.
+func (e *Engine) NewSession(opts ...Opts) (*Console, error) {
+	return newConsole(e, append(append([]Opts{WithEnvPrefix("")}, e.opts...), opts...)...)
+}
+
+// commands returns the registered commands. Commands are only ever appended,
+// so the slice is shared with the caller instead of copied.
+func (e *Engine) commands() []*Cmd {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return slices.Clip(e.cmds)
+}
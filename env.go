@@ -0,0 +1,54 @@
+package console
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const defaultEnvPrefix = "CONSOLE"
+
+// WithEnvPrefix sets the prefix of the environment variables overriding the
+// options, including the ones of a config file, see Config.Opts.
+// The default prefix is CONSOLE, an empty prefix disables the overrides.
+// Sessions of an Engine and child consoles only read the environment
+// if they are created with a prefix.
+func WithEnvPrefix(prefix string) Opts {
+	return func(c *Console) {
+		c.envPrefix = prefix
+	}
+}
+
+// applyEnv applies the environment variables after all options:
+//
+//	<PREFIX>_PROMPT        prompt
+//	<PREFIX>_HISTORY_FILE  history file, empty to disable the history
+//	<PREFIX>_NO_COLOR      disable colors if set to any value
+//	<PREFIX>_TIMEOUT       default command timeout, e.g. 30s
+func (c *Console) applyEnv() error {
+	if c.envPrefix == "" {
+		return nil
+	}
+	env := func(name string) (string, bool) {
+		return os.LookupEnv(c.envPrefix + "_" + name)
+	}
+	if v, ok := env("PROMPT"); ok {
+		c.prompt = v
+	}
+	if v, ok := env("HISTORY_FILE"); ok {
+		c.historyFile = expandHome(v)
+	}
+	if _, ok := env("NO_COLOR"); ok {
+		c.theme = Theme{Error: lipgloss.NewStyle(), Stack: lipgloss.NewStyle()}
+	}
+	if v, ok := env("TIMEOUT"); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s_TIMEOUT: %w", c.envPrefix, err)
+		}
+		c.defaultTimeout = d
+	}
+	return nil
+}
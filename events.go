@@ -0,0 +1,110 @@
+package console
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// EventType is the type of an Event.
+type EventType int
+
+const (
+	// EventStart is emitted by Start after the authentication.
+	EventStart EventType = iota
+	// EventPrompt is emitted before the prompt is shown.
+	EventPrompt
+	// EventCommandStart is emitted before the handler of a command runs.
+	EventCommandStart
+	// EventCommandEnd is emitted after the handler of a command returned.
+	EventCommandEnd
+	// EventError is emitted if a command fails or is refused.
+	EventError
+	// EventExit is emitted once the console is closed.
+	EventExit
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventStart:
+		return "start"
+	case EventPrompt:
+		return "prompt"
+	case EventCommandStart:
+		return "command_start"
+	case EventCommandEnd:
+		return "command_end"
+	case EventError:
+		return "error"
+	case EventExit:
+		return "exit"
+	}
+	return fmt.Sprintf("EventType(%d)", int(t))
+}
+
+// Event describes something that happened in a console.
+// Command, Input and Args are set for command events, Duration for
+// EventCommandEnd, Err for EventCommandEnd and EventError and Prompt
+// for EventPrompt.
+type Event struct {
+	Type     EventType
+	Console  *Console
+	Command  *Cmd
+	Input    string
+	Args     []string
+	Duration time.Duration
+	Err      error
+	Prompt   string
+}
+
+// Hook is called synchronously when an event is emitted.
+// Hooks of commands running concurrently may be called concurrently.
+type Hook func(e Event)
+
+type hook struct {
+	fn Hook
+}
+
+// WithHook subscribes to events of type t.
+func WithHook(t EventType, h Hook) Opts {
+	return func(c *Console) {
+		c.On(t, h)
+	}
+}
+
+// On subscribes to events of type t. Hooks are called in the order they
+// subscribed. The returned function unsubscribes the hook.
+func (c *Console) On(t EventType, h Hook) (unsubscribe func()) {
+	entry := &hook{fn: h}
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	if c.hooks == nil {
+		c.hooks = make(map[EventType][]*hook)
+	}
+	c.hooks[t] = append(c.hooks[t], entry)
+	return func() {
+		c.hooksMu.Lock()
+		defer c.hooksMu.Unlock()
+		c.hooks[t] = slices.DeleteFunc(slices.Clone(c.hooks[t]), func(e *hook) bool { return e == entry })
+	}
+}
+
+func (c *Console) emit(e Event) {
+	c.hooksMu.RLock()
+	hooks := c.hooks[e.Type]
+	c.hooksMu.RUnlock()
+	e.Console = c
+	for _, h := range hooks {
+		c.callHook(h, e)
+	}
+}
+
+// callHook keeps a panicking hook from breaking the console.
+func (c *Console) callHook(h *hook, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.logger.Error("hook panicked", "event", e.Type.String(), "panic", r)
+		}
+	}()
+	h.fn(e)
+}
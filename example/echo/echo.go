@@ -37,7 +37,7 @@ var echoCmd = &console.Cmd{
 	Name:        "echo",
 	Description: "echo",
 	Handler: func(c *console.Console, args []string) error {
-		fmt.Println(strings.Join(args, " "))
+		c.Println(strings.Join(args, " "))
 		return nil
 	},
 }
@@ -0,0 +1,99 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+
+	"github.com/jon4hz/console/parse"
+)
+
+// ExitCodeKey is the session key the exit code of the last process run by Exec is stored in.
+const ExitCodeKey = "?"
+
+// WithExec registers the exec builtin running external processes, see Exec.
+func WithExec() Opts {
+	return func(c *Console) {
+		c.execCmd = true
+	}
+}
+
+// envName matches the session keys exported to processes.
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Exec runs a process in the working directory of the session and streams
+// its stdout and stderr to the output of the command running with ctx.
+// The environment of the process is the one of the console process with
+// the session values added, if their keys are valid variable names. The
+// process is killed once ctx is done.
+//
+// The exit code is returned and stored at ExitCodeKey. It's 127 if the
+// process can't be started. The error is an *exec.ExitError if the process
+// exited with another code than 0. During a dry-run, the command line is
+// printed instead and 0 is returned.
+func (c *Console) Exec(ctx context.Context, name string, args ...string) (int, error) {
+	if c.DryRun() {
+		fmt.Fprintln(c.Writer(ctx), c.msg(MsgWouldRun, parse.Join(append([]string{name}, args...))))
+		return 0, nil
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = c.WorkingDir()
+	cmd.Env = append(os.Environ(), c.environ()...)
+	w := c.Writer(ctx)
+	cmd.Stdout, cmd.Stderr = w, w
+
+	err := cmd.Run()
+	code := 0
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		code = exitErr.ExitCode()
+	case err != nil:
+		code = 127
+	}
+	c.Set(ExitCodeKey, code)
+	return code, err
+}
+
+// environ returns the session values as environment variables.
+func (c *Console) environ() []string {
+	c.storeMu.RLock()
+	defer c.storeMu.RUnlock()
+	var env []string
+	for k, v := range c.store {
+		if envName.MatchString(k) {
+			env = append(env, fmt.Sprintf("%s=%v", k, v))
+		}
+	}
+	sort.Strings(env)
+	return env
+}
+
+var execCmd = &Cmd{
+	Name:        "exec",
+	Description: "Run an external command",
+	descID:      MsgExecDescription,
+	builtin:     true,
+	// Exec prints the command line during a dry-run
+	SupportsDryRun: true,
+	// the arguments belong to the process
+	ParsesOutputFlag: true,
+	Args:             []*Arg{{Name: "command"}},
+	Completer: func(c *Console, args []string) []string {
+		if len(args) > 1 {
+			return c.completePath(args[len(args)-1], false)
+		}
+		return nil
+	},
+	ContextHandler: func(ctx context.Context, c *Console, args []string) error {
+		if len(args) == 0 {
+			return errors.New(c.msg(MsgUsageError, "exec <command> [args...]"))
+		}
+		_, err := c.Exec(ctx, args[0], args[1:]...)
+		return err
+	},
+}
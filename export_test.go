@@ -0,0 +1,25 @@
+package console
+
+func (c *Console) Complete(line string) []string {
+	return c.complete(line)
+}
+
+func (c *Console) HandleInput(input string) (bool, error) {
+	return c.handleInput(input)
+}
+
+func (c *Console) Prompt() string {
+	return c.currentPrompt()
+}
+
+func (c *Console) HistoryFile() string {
+	return c.historyFile
+}
+
+func (c *Console) Theme() Theme {
+	return c.theme
+}
+
+func LocaleMessages(tag string) Messages {
+	return locales[tag]
+}
@@ -0,0 +1,393 @@
+// Package grpccmd turns the methods of gRPC services into console commands.
+//
+// Every unary and server streaming method becomes a command named after the
+// service and the method, like health.check for grpc.health.v1.Health/Check.
+// The fields of the request are set with flags:
+//
+//	--name value       scalar field, repeat the flag for repeated fields
+//	--address.city x   field of a nested message
+//	--json '{...}'     the whole request in its JSON form
+//	--output table     print the response as table instead of JSON
+//
+// Services are described by their descriptors, usually taken from the
+// generated code, or discovered with FromReflection.
+//
+// The package is a module of its own, so gRPC isn't a dependency of every console.
+package grpccmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jon4hz/console"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Commands returns a command for every unary and server streaming method
+// of the services. Client and bidirectional streaming methods are skipped.
+func Commands(conn grpc.ClientConnInterface, services ...protoreflect.ServiceDescriptor) []*console.Cmd {
+	var cmds []*console.Cmd
+	for _, sd := range services {
+		methods := sd.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			if md := methods.Get(i); !md.IsStreamingClient() {
+				cmds = append(cmds, methodCmd(conn, md))
+			}
+		}
+	}
+	return cmds
+}
+
+func methodCmd(conn grpc.ClientConnInterface, md protoreflect.MethodDescriptor) *console.Cmd {
+	name := kebab(string(md.Parent().Name())) + "." + kebab(string(md.Name()))
+	return &console.Cmd{
+		Name:        name,
+		Description: fmt.Sprintf("Call %s", md.FullName()),
+		// --output is json or table, defaulting to json, unlike the console's
+		ParsesOutputFlag: true,
+		Completer: func(_ *console.Console, args []string) []string {
+			return complete(md.Input(), args)
+		},
+		ContextHandler: func(ctx context.Context, c *console.Console, args []string) error {
+			req := dynamicpb.NewMessage(md.Input())
+			output, err := parseFlags(req, args)
+			if errors.Is(err, errHelp) {
+				c.Println(usage(name, md.Input()))
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return call(ctx, conn, md, req, func(resp *dynamicpb.Message) error {
+				return printMessage(c.Writer(ctx), resp, output)
+			})
+		},
+	}
+}
+
+// methodPath returns the path of the method used on the wire.
+func methodPath(md protoreflect.MethodDescriptor) string {
+	return fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name())
+}
+
+func call(ctx context.Context, conn grpc.ClientConnInterface, md protoreflect.MethodDescriptor, req *dynamicpb.Message, handle func(*dynamicpb.Message) error) error {
+	if !md.IsStreamingServer() {
+		resp := dynamicpb.NewMessage(md.Output())
+		if err := conn.Invoke(ctx, methodPath(md), req, resp); err != nil {
+			return err
+		}
+		return handle(resp)
+	}
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, methodPath(md))
+	if err != nil {
+		return err
+	}
+	if err := stream.SendMsg(req); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	for {
+		resp := dynamicpb.NewMessage(md.Output())
+		if err := stream.RecvMsg(resp); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := handle(resp); err != nil {
+			return err
+		}
+	}
+}
+
+var errHelp = errors.New("help requested")
+
+// parseFlags sets the fields of req from args and returns the output format.
+func parseFlags(req *dynamicpb.Message, args []string) (output string, err error) {
+	output = "json"
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "" {
+			continue
+		}
+		if a == "--help" || a == "-h" {
+			return "", errHelp
+		}
+		if !strings.HasPrefix(a, "--") {
+			return "", fmt.Errorf("unexpected argument %s", a)
+		}
+		name, value, hasValue := strings.Cut(a[2:], "=")
+		fd := lookup(req.Descriptor(), name)
+		if !hasValue && !(fd != nil && fd.Kind() == protoreflect.BoolKind && !fd.IsList()) {
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("flag %s needs a value", a)
+			}
+			i++
+			value = args[i]
+		} else if !hasValue {
+			value = "true"
+		}
+		switch name {
+		case "json":
+			if err := protojson.Unmarshal([]byte(value), req); err != nil {
+				return "", fmt.Errorf("invalid request: %w", err)
+			}
+			continue
+		case "output":
+			if value != "json" && value != "table" {
+				return "", fmt.Errorf("invalid output %s, must be json or table", value)
+			}
+			output = value
+			continue
+		}
+		if err := setField(req, name, value); err != nil {
+			return "", err
+		}
+	}
+	return output, nil
+}
+
+// lookup returns the field at the dotted path or nil.
+func lookup(md protoreflect.MessageDescriptor, path string) protoreflect.FieldDescriptor {
+	parts := strings.Split(path, ".")
+	for i, p := range parts {
+		fd := field(md, p)
+		if fd == nil {
+			return nil
+		}
+		if i == len(parts)-1 {
+			return fd
+		}
+		if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+			return nil
+		}
+		md = fd.Message()
+	}
+	return nil
+}
+
+// field returns the field named like the flag name, which is the kebab-cased proto name.
+func field(md protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		if fd := fields.Get(i); flagName(fd) == name || string(fd.Name()) == name {
+			return fd
+		}
+	}
+	return nil
+}
+
+func flagName(fd protoreflect.FieldDescriptor) string {
+	return strings.ReplaceAll(string(fd.Name()), "_", "-")
+}
+
+func setField(msg protoreflect.Message, path, value string) error {
+	parts := strings.Split(path, ".")
+	for _, p := range parts[:len(parts)-1] {
+		fd := field(msg.Descriptor(), p)
+		if fd == nil || fd.Message() == nil || fd.IsList() || fd.IsMap() {
+			return fmt.Errorf("unknown flag --%s", path)
+		}
+		msg = msg.Mutable(fd).Message()
+	}
+	fd := field(msg.Descriptor(), parts[len(parts)-1])
+	if fd == nil || fd.IsMap() || fd.Message() != nil {
+		return fmt.Errorf("unknown flag --%s", path)
+	}
+	v, err := scalar(fd, value)
+	if err != nil {
+		return fmt.Errorf("invalid value %q for --%s: %w", value, path, err)
+	}
+	if fd.IsList() {
+		msg.Mutable(fd).List().Append(v)
+		return nil
+	}
+	msg.Set(fd, v)
+	return nil
+}
+
+func scalar(fd protoreflect.FieldDescriptor, s string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(s)), nil
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("unknown %s value", fd.Enum().Name())
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(s, 0, 32)
+		return protoreflect.ValueOfInt32(int32(n)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(s, 0, 64)
+		return protoreflect.ValueOfInt64(n), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(s, 0, 32)
+		return protoreflect.ValueOfUint32(uint32(n)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(s, 0, 64)
+		return protoreflect.ValueOfUint64(n), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(f), err
+	}
+	return protoreflect.Value{}, fmt.Errorf("unsupported kind %s", fd.Kind())
+}
+
+// flags returns the flag names of the scalar fields of md, including nested messages.
+func flags(md protoreflect.MessageDescriptor, prefix string, depth int) (s []string) {
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		switch {
+		case fd.IsMap():
+		case fd.Message() != nil:
+			// recursive messages would never end
+			if !fd.IsList() && depth < 3 {
+				s = append(s, flags(fd.Message(), prefix+flagName(fd)+".", depth+1)...)
+			}
+		default:
+			s = append(s, prefix+flagName(fd))
+		}
+	}
+	return s
+}
+
+func complete(md protoreflect.MessageDescriptor, args []string) (s []string) {
+	cur := args[len(args)-1]
+	if n := len(args); n > 1 && strings.HasPrefix(args[n-2], "--") && !strings.Contains(args[n-2], "=") {
+		switch name := args[n-2][2:]; name {
+		case "output":
+			return withPrefix([]string{"json", "table"}, cur)
+		default:
+			if fd := lookup(md, name); fd != nil && fd.Kind() == protoreflect.EnumKind {
+				values := fd.Enum().Values()
+				var names []string
+				for i := 0; i < values.Len(); i++ {
+					names = append(names, string(values.Get(i).Name()))
+				}
+				return withPrefix(names, cur)
+			}
+			if fd := lookup(md, name); fd != nil && fd.Kind() != protoreflect.BoolKind {
+				return nil
+			}
+		}
+	}
+	if !strings.HasPrefix(cur, "-") && cur != "" {
+		return nil
+	}
+	var names []string
+	for _, f := range append(flags(md, "", 0), "json", "output") {
+		names = append(names, "--"+f)
+	}
+	return withPrefix(names, cur)
+}
+
+func withPrefix(values []string, prefix string) (s []string) {
+	for _, v := range values {
+		if strings.HasPrefix(v, prefix) {
+			s = append(s, v)
+		}
+	}
+	return
+}
+
+func usage(name string, md protoreflect.MessageDescriptor) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Usage: %s [flags]\n\nFlags:\n", name)
+	tw := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	for _, f := range flags(md, "", 0) {
+		fd := lookup(md, f)
+		typ := fd.Kind().String()
+		if fd.Kind() == protoreflect.EnumKind {
+			typ = string(fd.Enum().Name())
+		}
+		if fd.IsList() {
+			typ += " (repeated)"
+		}
+		fmt.Fprintf(tw, "  --%s\t%s\n", f, typ)
+	}
+	fmt.Fprintf(tw, "  --json\twhole request as JSON\n")
+	fmt.Fprintf(tw, "  --output\tjson or table\n")
+	tw.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func printMessage(w io.Writer, msg *dynamicpb.Message, output string) error {
+	if output == "json" {
+		b, err := protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true}.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	}
+	var rows [][2]string
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		rows = append(rows, [2]string{string(fd.Name()), formatValue(fd, v)})
+		return true
+	})
+	sort.Slice(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%s\t%s\n", r[0], r[1])
+	}
+	return tw.Flush()
+}
+
+func formatValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	switch {
+	case fd.IsList(), fd.IsMap(), fd.Message() != nil:
+		// composite values are printed in their JSON form
+		m := dynamicpb.NewMessage(fd.ContainingMessage())
+		m.Set(fd, v)
+		b, err := protojson.Marshal(m)
+		if err != nil {
+			return v.String()
+		}
+		s := string(b)
+		return strings.TrimSpace(s[strings.Index(s, ":")+1 : len(s)-1])
+	case fd.Kind() == protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+	}
+	return v.String()
+}
+
+// kebab converts a Go style name like SayHello to say-hello.
+func kebab(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 && !(s[i-1] >= 'A' && s[i-1] <= 'Z') {
+				b.WriteByte('-')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
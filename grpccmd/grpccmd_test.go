@@ -0,0 +1,74 @@
+package grpccmd
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+
+	"github.com/jon4hz/console"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func dial(t *testing.T) *grpc.ClientConn {
+	l := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus("db", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(s, hs)
+	reflection.Register(s)
+	go s.Serve(l)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return l.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestCommands(t *testing.T) {
+	conn := dial(t)
+	cmds := Commands(conn, healthpb.File_grpc_health_v1_health_proto.Services().Get(0))
+	assert.Len(t, cmds, 2)
+
+	var out bytes.Buffer
+	c, err := console.New(console.WithOutput(&out))
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.RegisterCommands(cmds...))
+
+	assert.NoError(t, c.Run(context.Background(), "health.check --service db --output table"))
+	assert.Equal(t, "status  NOT_SERVING\n", out.String())
+
+	out.Reset()
+	assert.NoError(t, c.Run(context.Background(), `health.check --json {"service":""}`))
+	assert.Contains(t, out.String(), `"SERVING"`)
+
+	assert.Error(t, c.Run(context.Background(), "health.check --bogus 1"))
+	assert.Equal(t, []string{"--service"}, cmds[0].Completer(c, []string{"--s"}))
+	assert.Equal(t, []string{"table"}, cmds[0].Completer(c, []string{"--output", "t"}))
+}
+
+func TestFromReflection(t *testing.T) {
+	cmds, err := FromReflection(context.Background(), dial(t))
+	assert.NoError(t, err)
+	var names []string
+	for _, c := range cmds {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"health.check", "health.watch"}, names)
+}
+
+func TestParseFlags(t *testing.T) {
+	md := healthpb.File_grpc_health_v1_health_proto.Messages().ByName("HealthCheckResponse")
+	assert.Equal(t, []string{"status"}, flags(md, "", 0))
+	assert.Equal(t, "say-hello", kebab("SayHello"))
+}
@@ -0,0 +1,129 @@
+package grpccmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jon4hz/console"
+	"google.golang.org/grpc"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// reflectionServices are excluded from the discovered services.
+var reflectionServices = map[string]bool{
+	"grpc.reflection.v1.ServerReflection":      true,
+	"grpc.reflection.v1alpha.ServerReflection": true,
+}
+
+// FromReflection discovers the services of the server behind conn with
+// the server reflection protocol and returns the commands of their methods.
+func FromReflection(ctx context.Context, conn *grpc.ClientConn) ([]*console.Cmd, error) {
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.CloseSend()
+
+	r := &resolver{stream: stream, files: make(map[string]*descriptorpb.FileDescriptorProto)}
+	resp, err := r.request(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		if reflectionServices[s.GetName()] {
+			continue
+		}
+		names = append(names, s.GetName())
+		if err := r.fetch(&rpb.ServerReflectionRequest{
+			MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: s.GetName()},
+		}); err != nil {
+			return nil, err
+		}
+	}
+	files, err := r.registry()
+	if err != nil {
+		return nil, err
+	}
+	var services []protoreflect.ServiceDescriptor
+	for _, n := range names {
+		d, err := files.FindDescriptorByName(protoreflect.FullName(n))
+		if err != nil {
+			return nil, err
+		}
+		sd, ok := d.(protoreflect.ServiceDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a service", n)
+		}
+		services = append(services, sd)
+	}
+	return Commands(conn, services...), nil
+}
+
+// resolver collects the file descriptors of the services and their dependencies.
+type resolver struct {
+	stream rpb.ServerReflection_ServerReflectionInfoClient
+	files  map[string]*descriptorpb.FileDescriptorProto
+}
+
+func (r *resolver) request(req *rpb.ServerReflectionRequest) (*rpb.ServerReflectionResponse, error) {
+	if err := r.stream.Send(req); err != nil {
+		return nil, err
+	}
+	resp, err := r.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if e := resp.GetErrorResponse(); e != nil {
+		return nil, fmt.Errorf("reflection: %s", e.GetErrorMessage())
+	}
+	return resp, nil
+}
+
+// fetch requests the files of req and all their missing dependencies.
+func (r *resolver) fetch(req *rpb.ServerReflectionRequest) error {
+	resp, err := r.request(req)
+	if err != nil {
+		return err
+	}
+	var deps []string
+	for _, b := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		fd := new(descriptorpb.FileDescriptorProto)
+		if err := proto.Unmarshal(b, fd); err != nil {
+			return err
+		}
+		r.files[fd.GetName()] = fd
+		deps = append(deps, fd.GetDependency()...)
+	}
+	for _, d := range deps {
+		if _, ok := r.files[d]; ok {
+			continue
+		}
+		// well known types are linked into every binary
+		if fd, err := protoregistry.GlobalFiles.FindFileByPath(d); err == nil {
+			r.files[d] = protodesc.ToFileDescriptorProto(fd)
+			continue
+		}
+		if err := r.fetch(&rpb.ServerReflectionRequest{
+			MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: d},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *resolver) registry() (*protoregistry.Files, error) {
+	set := new(descriptorpb.FileDescriptorSet)
+	for _, fd := range r.files {
+		set.File = append(set.File, fd)
+	}
+	return protodesc.NewFiles(set)
+}
@@ -0,0 +1,149 @@
+package console
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// HelpOrder is the order of the commands listed by the help.
+type HelpOrder int
+
+const (
+	// HelpOrderRegistration lists the commands in the order they were registered,
+	// after the commands of the current mode and the builtins.
+	HelpOrderRegistration HelpOrder = iota
+	// HelpOrderAlphabetical lists the commands sorted by name.
+	HelpOrderAlphabetical
+	// HelpOrderGroup lists the commands without a group followed by a section
+	// per group. Groups and the commands within them are sorted by name.
+	// Commands of a namespace without a group are grouped by the namespace.
+	HelpOrderGroup
+)
+
+// WithHelpOrder sets the order of the commands in the help.
+// The exit command is always listed last.
+func WithHelpOrder(o HelpOrder) Opts {
+	return func(c *Console) {
+		c.helpOrder = o
+	}
+}
+
+type helpEntry struct {
+	name, desc, group string
+	cmd               *Cmd
+}
+
+// RenderHelp returns the help listing the permitted commands, like shown by the help command.
+func (c *Console) RenderHelp() string {
+	entries := c.helpEntries()
+	if c.helpOrder != HelpOrderRegistration {
+		slices.SortStableFunc(entries, func(a, b helpEntry) int {
+			if c.helpOrder == HelpOrderGroup && a.group != b.group {
+				return strings.Compare(a.group, b.group)
+			}
+			return strings.Compare(a.name, b.name)
+		})
+	}
+	s := c.msg(MsgAvailableCommands)
+	var group string
+	for _, e := range entries {
+		if c.helpOrder == HelpOrderGroup && e.group != group {
+			group = e.group
+			s += fmt.Sprintf("\n\n%s:", group)
+		}
+		s += fmt.Sprintf("\n  %s - %s", e.name, e.desc)
+	}
+	if c.exitCmd != nil && c.exitCmd.permitted(c) {
+		if c.helpOrder == HelpOrderGroup && group != "" {
+			s += "\n"
+		}
+		s += fmt.Sprintf("\n  %s - %s", c.exitCmd.Name, c.msg(MsgExitConsole))
+	}
+	return s
+}
+
+// helpEntries returns the permitted commands with a description.
+func (c *Console) helpEntries() []helpEntry {
+	var entries []helpEntry
+	for _, cmd := range c.commands() {
+		if !cmd.permitted(c) {
+			continue
+		}
+		if cmd.subs != nil {
+			for _, sub := range cmd.subs {
+				if sub.permitted(c) && sub.Description != "" {
+					group := sub.Group
+					if group == "" {
+						group = cmd.Group
+					}
+					if group == "" {
+						group = cmd.Name
+					}
+					entries = append(entries, helpEntry{sub.Name, c.description(sub), group, sub})
+				}
+			}
+			continue
+		}
+		if desc := c.description(cmd); cmd.Name != "" && desc != "" {
+			entries = append(entries, helpEntry{cmd.Name, desc, cmd.Group, cmd})
+		}
+	}
+	return entries
+}
+
+// Apropos returns the permitted commands whose name, aliases, description,
+// long help, group or arguments contain keyword, ignoring case, like shown by help -k.
+// Commands registered with RegisterLazy are only matched by their name and
+// description, so searching doesn't load them.
+func (c *Console) Apropos(keyword string) string {
+	keyword = strings.ToLower(keyword)
+	var matches []helpEntry
+	for _, e := range c.helpEntries() {
+		if e.matches(keyword) {
+			matches = append(matches, e)
+		}
+	}
+	if desc := c.msg(MsgExitConsole); c.exitCmd != nil && c.exitCmd.permitted(c) {
+		if e := (helpEntry{name: c.exitCmd.Name, desc: desc, cmd: c.exitCmd}); e.matches(keyword) {
+			matches = append(matches, e)
+		}
+	}
+	if len(matches) == 0 {
+		return c.msg(MsgNoMatches, keyword)
+	}
+	slices.SortStableFunc(matches, func(a, b helpEntry) int { return strings.Compare(a.name, b.name) })
+	var b strings.Builder
+	for i, e := range matches {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "  %s - %s", e.name, e.desc)
+	}
+	return b.String()
+}
+
+// matches reports whether the lowercase keyword is part of the entry.
+func (e helpEntry) matches(keyword string) bool {
+	contains := func(s string) bool { return strings.Contains(strings.ToLower(s), keyword) }
+	if contains(e.name) || contains(e.desc) || contains(e.group) {
+		return true
+	}
+	if e.cmd.lazy != nil {
+		return false
+	}
+	if contains(e.cmd.LongHelp) {
+		return true
+	}
+	for _, a := range e.cmd.Aliases {
+		if contains(a) {
+			return true
+		}
+	}
+	for _, a := range e.cmd.Args {
+		if contains(a.Name) || contains(a.Description) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package console
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// RegisterLazy registers a command which is created by load on its first use,
+// i.e. when it's run or its arguments are completed. Until then, it's only
+// known by its name and description, so registering many commands is cheap.
+func (c *Console) RegisterLazy(name, description string, load func() *Cmd) error {
+	return c.RegisterCommands(LazyCmd(name, description, load))
+}
+
+// LazyCmd creates a command loaded on first use, see RegisterLazy.
+// It can also be registered at an Engine. The loaded command must have the
+// same name or no name at all, its aliases aren't matched.
+func LazyCmd(name, description string, load func() *Cmd) *Cmd {
+	cmd := &Cmd{
+		Name:        name,
+		Description: description,
+		lazy:        &lazyCmd{load: load},
+	}
+	// only reached if the command can't be loaded or by Cmd.Handle
+	cmd.ContextHandler = func(ctx context.Context, c *Console, args []string) error {
+		loaded, err := cmd.lazy.get(cmd)
+		if err != nil {
+			return err
+		}
+		return loaded.handle(ctx, c, args)
+	}
+	cmd.Completer = func(c *Console, args []string) []string {
+		if loaded, err := cmd.lazy.get(cmd); err == nil {
+			return c.completeCmdArgs(loaded, args)
+		}
+		return nil
+	}
+	return cmd
+}
+
+type lazyCmd struct {
+	once sync.Once
+	load func() *Cmd
+	cmd  *Cmd
+	err  error
+}
+
+// get loads the command of the placeholder p once.
+func (l *lazyCmd) get(p *Cmd) (*Cmd, error) {
+	l.once.Do(func() {
+		cmd := l.load()
+		switch {
+		case cmd == nil:
+			l.err = fmt.Errorf("command %s: not loaded", p.Name)
+		case cmd.bindErr != nil:
+			l.err = cmd.bindErr
+		case cmd.Name != "" && cmd.Name != p.Name:
+			l.err = fmt.Errorf("command %s: loaded command is named %s", p.Name, cmd.Name)
+		default:
+			cmd.Name = p.Name
+			if cmd.Description == "" {
+				cmd.Description = p.Description
+			}
+			if cmd.Console == nil {
+				cmd.Console = p.Console
+			}
+			l.cmd = cmd
+		}
+	})
+	return l.cmd, l.err
+}
+
+// resolve returns the loaded command of a lazy command or c itself.
+// If the command can't be loaded, c is returned, which fails with the error.
+func (c *Cmd) resolve() *Cmd {
+	if c.lazy == nil {
+		return c
+	}
+	if loaded, err := c.lazy.get(c); err == nil {
+		return loaded
+	}
+	return c
+}
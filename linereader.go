@@ -0,0 +1,93 @@
+package console
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"github.com/peterh/liner"
+	"golang.org/x/term"
+)
+
+// ErrPromptAborted is returned by a LineReader if the user aborted the prompt, e.g. with ctrl-c.
+var ErrPromptAborted = errors.New("prompt aborted")
+
+// LineReader reads the input of the user line by line.
+type LineReader interface {
+	Prompt(prompt string) (string, error)
+	PasswordPrompt(prompt string) (string, error)
+	SetCompleter(f func(line string) []string)
+	AppendHistory(item string)
+	ReadHistory(r io.Reader) (int, error)
+	WriteHistory(w io.Writer) (int, error)
+	Close() error
+}
+
+// WithLineReader reads the input from lr instead of the terminal attached to stdin.
+// The console doesn't check if the input is a pipe.
+func WithLineReader(lr LineReader) Opts {
+	return func(c *Console) {
+		c.reader = lr
+	}
+}
+
+// WithOutput writes the output of the console to w instead of stdout.
+func WithOutput(w io.Writer) Opts {
+	return func(c *Console) {
+		c.out = w
+	}
+}
+
+// valuePicker is implemented by line readers supporting both tab completion styles.
+type valuePicker interface {
+	setValuePicker(enabled bool)
+}
+
+// terminalSizer is implemented by line readers knowing the size of the terminal.
+type terminalSizer interface {
+	terminalSize() (width, height int, ok bool)
+}
+
+// linerReader reads lines from stdin using liner.
+type linerReader struct {
+	*liner.State
+}
+
+func newLinerReader(ctrlCAborts bool) *linerReader {
+	l := liner.NewLiner()
+	l.SetCtrlCAborts(ctrlCAborts)
+	return &linerReader{l}
+}
+
+func (l *linerReader) Prompt(prompt string) (string, error) {
+	s, err := l.State.Prompt(prompt)
+	if err == liner.ErrPromptAborted {
+		return s, ErrPromptAborted
+	}
+	return s, err
+}
+
+func (l *linerReader) PasswordPrompt(prompt string) (string, error) {
+	s, err := l.State.PasswordPrompt(prompt)
+	if err == liner.ErrPromptAborted {
+		return s, ErrPromptAborted
+	}
+	return s, err
+}
+
+func (l *linerReader) SetCompleter(f func(line string) []string) {
+	l.State.SetCompleter(f)
+}
+
+func (l *linerReader) terminalSize() (width, height int, ok bool) {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	return width, height, err == nil
+}
+
+func (l *linerReader) setValuePicker(enabled bool) {
+	if enabled {
+		l.SetTabCompletionStyle(liner.TabCircular)
+	} else {
+		l.SetTabCompletionStyle(liner.TabPrints)
+	}
+}
@@ -0,0 +1,322 @@
+// Package luascript lets users define console commands in Lua scripts.
+//
+// Scripts run in a sandbox with the base, table, string and math libraries
+// only. They can't access files, the environment or other processes except
+// through the console API:
+//
+//	console.register{name = "greet", description = "Greet someone", aliases = {"hi"},
+//		run = function(args) console.print("hello " .. (args[1] or "world")) end}
+//	console.run("help")           -- returns true or nil and the error message
+//	console.print("a", 1)         -- prints its arguments separated by spaces
+//	console.set("key", "value")   -- sets a session value
+//	console.get("key")            -- returns a session value or nil
+//
+// A command fails if its run function raises an error.
+package luascript
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jon4hz/console"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// unsafeBaseFuncs are removed from the base library.
+var unsafeBaseFuncs = []string{"dofile", "loadfile", "require", "module", "_printregs"}
+
+// Engine runs the scripts of a console.
+type Engine struct {
+	c *console.Console
+
+	// mu serializes the access to the Lua state. It's released while
+	// console.run executes a command, which may be a script command as well.
+	mu sync.Mutex
+	L  *lua.LState
+	// loading collects the commands of the script being loaded.
+	loading *[]*console.Cmd
+	scripts map[string][]*console.Cmd
+}
+
+// New creates a script engine registering its commands with c.
+func New(c *console.Console) *Engine {
+	e := &Engine{
+		c:       c,
+		L:       lua.NewState(lua.Options{SkipOpenLibs: true}),
+		scripts: make(map[string][]*console.Cmd),
+	}
+	for _, lib := range []struct {
+		name string
+		fn   lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		e.L.Push(e.L.NewFunction(lib.fn))
+		e.L.Push(lua.LString(lib.name))
+		e.L.Call(1, 0)
+	}
+	for _, fn := range unsafeBaseFuncs {
+		e.L.SetGlobal(fn, lua.LNil)
+	}
+	e.L.SetGlobal("console", e.L.SetFuncs(e.L.NewTable(), map[string]lua.LGFunction{
+		"register": e.register,
+		"run":      e.run,
+		"print":    e.print,
+		"set":      e.set,
+		"get":      e.get,
+	}))
+	return e
+}
+
+// Close closes the Lua state. The commands of the scripts must not be run afterwards.
+func (e *Engine) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.L.Close()
+}
+
+// LoadFile runs the script at path. Commands registered by an earlier
+// run of the same script are replaced.
+func (e *Engine) LoadFile(path string) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return e.LoadString(path, string(src))
+}
+
+// LoadString runs the script src. The name identifies the script.
+// The commands of the script are registered once it ran successfully,
+// and replace the ones of an earlier run. If it fails, the commands of
+// the earlier run are kept.
+func (e *Engine) LoadString(name, src string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	fn, err := e.L.LoadString(src)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	var cmds []*console.Cmd
+	e.loading = &cmds
+	e.L.Push(fn)
+	err = e.L.PCall(0, 0, nil)
+	e.loading = nil
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, luaError(err))
+	}
+
+	old := e.scripts[name]
+	e.c.UnregisterCommands(old...)
+	if err := e.c.RegisterCommands(cmds...); err != nil {
+		e.c.RegisterCommands(old...)
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if len(cmds) == 0 {
+		delete(e.scripts, name)
+	} else {
+		e.scripts[name] = cmds
+	}
+	return nil
+}
+
+// LoadDir runs all .lua scripts in dir in lexical order.
+func (e *Engine) LoadDir(dir string) error {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.lua"))
+	if err != nil {
+		return err
+	}
+	for _, m := range matches {
+		if err := e.LoadFile(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Scripts returns the names of the scripts which registered commands.
+func (e *Engine) Scripts() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s := make([]string, 0, len(e.scripts))
+	for n := range e.scripts {
+		s = append(s, n)
+	}
+	sort.Strings(s)
+	return s
+}
+
+// Cmd returns the script builtin to load and list scripts.
+func (e *Engine) Cmd() *console.Cmd {
+	return &console.Cmd{
+		Name:        "script",
+		Description: "Load and list scripts",
+		Args:        []*console.Arg{{Name: "action", Values: []string{"list", "load"}}},
+		ContextHandler: func(ctx context.Context, c *console.Console, args []string) error {
+			w := c.Writer(ctx)
+			switch {
+			case len(args) == 2 && args[0] == "load":
+				if err := e.LoadFile(args[1]); err != nil {
+					return err
+				}
+				fmt.Fprintf(w, "loaded %s\n", args[1])
+				return nil
+			case len(args) == 1 && args[0] == "list":
+				for _, s := range e.Scripts() {
+					fmt.Fprintln(w, s)
+				}
+				return nil
+			}
+			return errors.New("usage: script load <file> | script list")
+		},
+	}
+}
+
+func (e *Engine) register(L *lua.LState) int {
+	t := L.CheckTable(1)
+	name, ok := t.RawGetString("name").(lua.LString)
+	if !ok || name == "" {
+		L.ArgError(1, "name is required")
+	}
+	fn, ok := t.RawGetString("run").(*lua.LFunction)
+	if !ok {
+		L.ArgError(1, "run must be a function")
+	}
+	cmd := &console.Cmd{
+		Name:        string(name),
+		Description: lua.LVAsString(t.RawGetString("description")),
+		// scripts get all arguments
+		ParsesOutputFlag: true,
+		ContextHandler: func(ctx context.Context, _ *console.Console, args []string) error {
+			return e.call(ctx, fn, args)
+		},
+	}
+	if aliases, ok := t.RawGetString("aliases").(*lua.LTable); ok {
+		aliases.ForEach(func(_, v lua.LValue) {
+			cmd.Aliases = append(cmd.Aliases, v.String())
+		})
+	}
+	if e.loading != nil {
+		*e.loading = append(*e.loading, cmd)
+		return 0
+	}
+	// registered by a command, not while loading a script
+	if err := e.c.RegisterCommands(cmd); err != nil {
+		L.RaiseError("failed to register %s: %s", name, err)
+	}
+	e.scripts[""] = append(e.scripts[""], cmd)
+	return 0
+}
+
+// call runs a script command in its own thread of the Lua state.
+func (e *Engine) call(ctx context.Context, fn *lua.LFunction, args []string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.L.IsClosed() {
+		return errors.New("script engine closed")
+	}
+	th, _ := e.L.NewThread()
+	defer th.Close()
+	th.SetContext(ctx)
+	t := th.NewTable()
+	for _, a := range args {
+		t.Append(lua.LString(a))
+	}
+	if err := th.CallByParam(lua.P{Fn: fn, NRet: 0, Protect: true}, t); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return luaError(err)
+	}
+	return nil
+}
+
+func (e *Engine) run(L *lua.LState) int {
+	line := L.CheckString(1)
+	e.mu.Unlock()
+	err := e.c.Run(luaContext(L), line)
+	e.mu.Lock()
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+	L.Push(lua.LTrue)
+	return 1
+}
+
+func (e *Engine) print(L *lua.LState) int {
+	parts := make([]string, L.GetTop())
+	for i := range parts {
+		parts[i] = L.ToStringMeta(L.Get(i + 1)).String()
+	}
+	fmt.Fprintln(e.c.Writer(luaContext(L)), strings.Join(parts, " "))
+	return 0
+}
+
+func (e *Engine) set(L *lua.LState) int {
+	key := L.CheckString(1)
+	switch v := L.Get(2).(type) {
+	case lua.LString:
+		e.c.Set(key, string(v))
+	case lua.LNumber:
+		e.c.Set(key, float64(v))
+	case lua.LBool:
+		e.c.Set(key, bool(v))
+	case *lua.LNilType:
+		e.c.Delete(key)
+	default:
+		L.ArgError(2, "value must be a string, number, boolean or nil")
+	}
+	return 0
+}
+
+func (e *Engine) get(L *lua.LState) int {
+	v, ok := e.c.Get(L.CheckString(1))
+	if !ok {
+		L.Push(lua.LNil)
+		return 1
+	}
+	switch v := v.(type) {
+	case string:
+		L.Push(lua.LString(v))
+	case bool:
+		L.Push(lua.LBool(v))
+	case int:
+		L.Push(lua.LNumber(v))
+	case int64:
+		L.Push(lua.LNumber(v))
+	case float64:
+		L.Push(lua.LNumber(v))
+	default:
+		L.Push(lua.LString(fmt.Sprint(v)))
+	}
+	return 1
+}
+
+// luaContext returns the context of the command running L.
+// Scripts run at load time use the background context.
+func luaContext(L *lua.LState) context.Context {
+	if ctx := L.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+// luaError strips the Lua stack trace from err.
+func luaError(err error) error {
+	var apiErr *lua.ApiError
+	if errors.As(err, &apiErr) && apiErr.Object != nil {
+		return errors.New(apiErr.Object.String())
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package luascript
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jon4hz/console"
+	"github.com/stretchr/testify/assert"
+)
+
+const script = `
+console.register{name = "greet", aliases = {"hi"}, description = "Greet someone",
+	run = function(args) console.print("hello", args[1] or "world") end}
+console.register{name = "twice", run = function(args)
+	assert(console.run("greet " .. args[1]))
+	assert(console.run("hi"))
+	console.set("greeted", 2)
+end}
+console.register{name = "fail", run = function() error("boom", 0) end}
+`
+
+func TestScript(t *testing.T) {
+	var out bytes.Buffer
+	c, err := console.New(console.WithOutput(&out))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	e := New(c)
+	defer e.Close()
+	assert.NoError(t, c.RegisterCommands(e.Cmd()))
+
+	path := filepath.Join(t.TempDir(), "greet.lua")
+	assert.NoError(t, os.WriteFile(path, []byte(script), 0o600))
+	assert.NoError(t, c.Run(context.Background(), "script load "+path))
+	assert.Equal(t, []string{path}, e.Scripts())
+
+	assert.NoError(t, c.Run(context.Background(), "twice alice"))
+	assert.Contains(t, out.String(), "hello alice\nhello world\n")
+	v, _ := c.Get("greeted")
+	assert.Equal(t, float64(2), v)
+
+	assert.EqualError(t, c.Run(context.Background(), "fail"), "boom")
+
+	// reloading replaces the commands of the script
+	assert.NoError(t, e.LoadFile(path))
+	assert.NoError(t, c.Run(context.Background(), "greet"))
+
+	// a failing reload keeps the commands of the last successful run
+	for _, src := range []string{
+		`console.register{name = "greet", run = function() end`,
+		`console.register{name = "other", run = function() end} error("boom")`,
+		`console.register{name = "script", run = function() end}`,
+	} {
+		assert.NoError(t, os.WriteFile(path, []byte(src), 0o600))
+		assert.Error(t, e.LoadFile(path), src)
+		assert.NoError(t, c.Run(context.Background(), "twice bob"))
+		assert.Error(t, c.Run(context.Background(), "other"))
+		assert.Equal(t, []string{path}, e.Scripts())
+	}
+}
+
+func TestSandbox(t *testing.T) {
+	c, err := console.New()
+	assert.NoError(t, err)
+	defer c.Close()
+
+	e := New(c)
+	defer e.Close()
+	for _, src := range []string{`io.open("/etc/passwd")`, `os.exit(1)`, `dofile("x.lua")`, `require("os")`} {
+		assert.Error(t, e.LoadString("sandbox", src), src)
+	}
+	assert.NoError(t, e.LoadString("libs", `assert(string.upper("a") == "A" and math.max(1, 2) == 2 and table.concat({"a"}) == "a")`))
+}
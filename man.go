@@ -0,0 +1,191 @@
+package console
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WithPager pauses long help pages and other output printed with Page
+// after every screen. It's enabled by default if the size of the terminal
+// is known, i.e. for the local terminal and TerminalReaders with a size.
+func WithPager(enabled bool) Opts {
+	return func(c *Console) {
+		c.noPager = !enabled
+	}
+}
+
+// Page prints text like Println. If the pager is enabled and text doesn't
+// fit on the screen, it waits for enter after every screen; q stops.
+func (c *Console) Page(text string) error {
+	return c.page(c.out, text)
+}
+
+// page prints text to w, which is only paged if it's the output of the
+// console. The tagged output of concurrent commands and jobs isn't paged.
+func (c *Console) page(w io.Writer, text string) error {
+	height := c.pageHeight()
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if w != c.out || height <= 0 || len(lines) < height {
+		fmt.Fprintln(w, text)
+		return nil
+	}
+	for len(lines) > 0 {
+		n := min(height-1, len(lines))
+		fmt.Fprintln(w, strings.Join(lines[:n], "\n"))
+		lines = lines[n:]
+		if len(lines) == 0 {
+			break
+		}
+		in, err := c.reader.Prompt(c.msg(MsgMore))
+		if err != nil {
+			return err
+		}
+		if strings.EqualFold(strings.TrimSpace(in), "q") {
+			break
+		}
+	}
+	return nil
+}
+
+// pageHeight returns the number of lines of a page or 0 if output isn't paged.
+func (c *Console) pageHeight() int {
+	if c.noPager || c.isOsPipe {
+		return 0
+	}
+	if ts, ok := c.reader.(terminalSizer); ok {
+		if _, height, ok := ts.terminalSize(); ok && height > 1 {
+			return height
+		}
+	}
+	return 0
+}
+
+// RenderManPage returns the help page of the command with the given name
+// like shown by help <command>. A name of a namespace lists its commands,
+// a namespace followed by a command name returns the page of the command.
+func (c *Console) RenderManPage(name ...string) (string, error) {
+	cmd := c.helpTarget(name)
+	if cmd == nil {
+		return "", fmt.Errorf("%w: %s", ErrCmdNotFound, strings.Join(name, " "))
+	}
+	cmd = cmd.resolve()
+	if cmd.subs != nil {
+		return namespaceView(c, cmd), nil
+	}
+
+	var b strings.Builder
+	section := func(title string, lines ...string) {
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString(title)
+		for _, l := range lines {
+			b.WriteString("\n")
+			if l != "" {
+				b.WriteString("    " + l)
+			}
+		}
+	}
+	desc := c.description(cmd)
+	if cmd == c.exitCmd {
+		desc = c.msg(MsgExitConsole)
+	}
+	nameLine := cmd.Name
+	if desc != "" {
+		nameLine += " - " + desc
+	}
+	section(c.msg(MsgManName), nameLine)
+	if len(cmd.Aliases) > 0 {
+		section(c.msg(MsgManAliases), strings.Join(cmd.Aliases, ", "))
+	}
+	section(c.msg(MsgManUsage), strings.Split(c.cmdUsage(cmd), "\n")...)
+	if cmd.LongHelp != "" {
+		section(c.msg(MsgManDescription), strings.Split(strings.TrimSpace(cmd.LongHelp), "\n")...)
+	}
+	if len(cmd.Examples) > 0 {
+		section(c.msg(MsgManExamples), cmd.Examples...)
+	}
+	if len(cmd.SeeAlso) > 0 {
+		section(c.msg(MsgManSeeAlso), strings.Join(cmd.SeeAlso, ", "))
+	}
+	return b.String(), nil
+}
+
+// helpTarget returns the permitted command named by the words of name.
+func (c *Console) helpTarget(name []string) *Cmd {
+	if len(name) == 0 {
+		return nil
+	}
+	cmd := c.exitCmd
+	if cmd == nil || !cmd.hasName(name[0]) {
+		cmd = nil
+		for _, n := range c.commands() {
+			if n.hasName(name[0]) {
+				cmd = n
+				break
+			}
+		}
+	}
+	if cmd == nil || !cmd.permitted(c) {
+		return nil
+	}
+	if len(name) == 1 {
+		return cmd
+	}
+	cmd = cmd.resolve()
+	if cmd.subs == nil || len(name) > 2 {
+		return nil
+	}
+	if sub := cmd.sub(name[1]); sub != nil && sub.permitted(c) {
+		return sub
+	}
+	return nil
+}
+
+// cmdUsage returns the usage of cmd. Commands with bound parameters,
+// see Bind, show their flags. For others the usage is made of the Args.
+func (c *Console) cmdUsage(cmd *Cmd) string {
+	if cmd.binding != nil {
+		return cmd.binding.usage(c, cmd.Name)
+	}
+	s := c.msg(MsgUsage, cmd.Name)
+	for _, a := range cmd.Args {
+		s += " [<" + a.Name + ">]"
+	}
+	var args []string
+	for _, a := range cmd.Args {
+		desc := a.Description
+		if len(a.Values) > 0 {
+			desc += " " + c.msg(MsgOneOf, strings.Join(a.Values, ", "))
+		}
+		if desc = strings.TrimSpace(desc); desc != "" {
+			args = append(args, fmt.Sprintf("\n  %-20s %s", a.Name, desc))
+		}
+	}
+	if len(args) > 0 {
+		s += "\n\n" + c.msg(MsgUsageArguments) + strings.Join(args, "")
+	}
+	return s
+}
+
+// completeHelp completes the arguments of the help builtin.
+func completeHelp(c *Console, args []string) (s []string) {
+	prefix := args[len(args)-1]
+	switch len(args) {
+	case 1:
+		if strings.HasPrefix("-k", prefix) {
+			s = append(s, "-k")
+		}
+		for _, cmd := range c.commands() {
+			if cmd.Name != "" && strings.HasPrefix(cmd.Name, prefix) && cmd.permitted(c) {
+				s = append(s, cmd.Name)
+			}
+		}
+	case 2:
+		if ns := c.helpTarget(args[:1]); ns != nil && ns.subs != nil {
+			s = ns.completeSub(c, prefix)
+		}
+	}
+	return
+}
@@ -0,0 +1,279 @@
+package console
+
+import "fmt"
+
+// MessageID identifies a built-in message. Messages are format strings
+// taking the arguments documented with their ID. Error values like
+// ErrPermissionDenied aren't translated, so they can still be compared.
+type MessageID string
+
+const (
+	MsgAvailableCommands   MessageID = "available_commands"    // help header
+	MsgExitConsole         MessageID = "exit_console"          // description of the exit command in the help
+	MsgNamespaceCommands   MessageID = "namespace_commands"    // namespace
+	MsgAborted             MessageID = "aborted"               // the prompt was aborted with ctrl+c
+	MsgErrorReadingLine    MessageID = "error_reading_line"    // error
+	MsgErrorRunningCmd     MessageID = "error_running_cmd"     // command, error
+	MsgCmdPanicked         MessageID = "cmd_panicked"          // command, panic value
+	MsgCmdDone             MessageID = "cmd_done"              // tag of a concurrent command
+	MsgCmdFailed           MessageID = "cmd_failed"            // tag of a concurrent command, error
+	MsgDangerousCmd        MessageID = "dangerous_cmd"         // command, danger level
+	MsgConfirmYesNo        MessageID = "confirm_yes_no"        // command
+	MsgConfirmYes          MessageID = "confirm_yes"           // answer confirming MsgConfirmYesNo
+	MsgConfirmType         MessageID = "confirm_type"          // text to type
+	MsgUsername            MessageID = "username"              // prompt of PasswordAuth
+	MsgPassword            MessageID = "password"              // prompt of PasswordAuth and the elevation
+	MsgNothingToUndo       MessageID = "nothing_to_undo"       // undo list
+	MsgUndoStack           MessageID = "undo_stack"            // undo list header
+	MsgUndoing             MessageID = "undoing"               // description of the operation
+	MsgHelpDescription     MessageID = "help_description"      // description of the help builtin
+	MsgClearDescription    MessageID = "clear_description"     // description of the clear builtin
+	MsgUndoDescription     MessageID = "undo_description"      // description of the undo builtin
+	MsgStatsDescription    MessageID = "stats_description"     // description of the stats builtin
+	MsgCalcDescription     MessageID = "calc_description"      // description of the calc builtin
+	MsgQuitDescription     MessageID = "quit_description"      // description of the quit builtin
+	MsgEnableDescription   MessageID = "enable_description"    // description of the enable builtin
+	MsgDisableDescription  MessageID = "disable_description"   // description of the disable builtin
+	MsgSudoDescription     MessageID = "sudo_description"      // description of the sudo builtin
+	MsgModeExitDescription MessageID = "mode_exit_description" // description of the exit command of modes
+	MsgVersionDescription  MessageID = "version_description"   // description of the version builtin
+	MsgCdDescription       MessageID = "cd_description"        // description of the cd builtin
+	MsgPwdDescription      MessageID = "pwd_description"       // description of the pwd builtin
+	MsgExecDescription     MessageID = "exec_description"      // description of the exec builtin
+	MsgEveryDescription    MessageID = "every_description"     // description of the every builtin
+	MsgAtDescription       MessageID = "at_description"        // description of the at builtin
+	MsgScheduleDescription MessageID = "schedule_description"  // description of the schedule builtin
+	MsgJobScheduled        MessageID = "job_scheduled"         // job ID
+	MsgNoJobs              MessageID = "no_jobs"               // schedule list
+	MsgFormatDescription   MessageID = "format_description"    // description of the format builtin
+	MsgNoMatches           MessageID = "no_matches"            // keyword searched with help -k
+	MsgMore                MessageID = "more"                  // prompt of the pager
+	MsgManName             MessageID = "man_name"              // section of a help page
+	MsgManAliases          MessageID = "man_aliases"           // section of a help page
+	MsgManUsage            MessageID = "man_usage"             // section of a help page
+	MsgManDescription      MessageID = "man_description"       // section of a help page
+	MsgManExamples         MessageID = "man_examples"          // section of a help page
+	MsgManSeeAlso          MessageID = "man_see_also"          // section of a help page
+	MsgDryRunDescription   MessageID = "dry_run_description"   // description of the dry-run builtin
+	MsgDryRunState         MessageID = "dry_run_state"         // on or off
+	MsgWouldRun            MessageID = "would_run"             // command line of a process not run during a dry-run
+	MsgCommandsDescription MessageID = "commands_description"  // description of the commands builtin
+	MsgUsageError          MessageID = "usage_error"           // syntax of a builtin called with invalid arguments
+	MsgUsage               MessageID = "usage"                 // syntax of a command in its usage
+	MsgUsageArguments      MessageID = "usage_arguments"       // section of a usage
+	MsgUsageFlags          MessageID = "usage_flags"           // section of a usage
+	MsgOneOf               MessageID = "one_of"                // values of an argument or flag
+	MsgDefault             MessageID = "default"               // default of an argument or flag
+	MsgRequired            MessageID = "required"              // required argument or flag
+	MsgNoStats             MessageID = "no_stats"              // stats before the first command
+	MsgStatsCommand        MessageID = "stats_command"         // column of the stats
+	MsgStatsCalls          MessageID = "stats_calls"           // column of the stats
+	MsgStatsErrors         MessageID = "stats_errors"          // column of the stats
+	MsgStatsAvg            MessageID = "stats_avg"             // column of the stats
+	MsgStatsMax            MessageID = "stats_max"             // column of the stats
+	MsgNamespaceDesc       MessageID = "namespace_description" // namespace
+	MsgUnknownNamespaceCmd MessageID = "unknown_namespace_cmd" // namespace, command
+	MsgInvalidJob          MessageID = "invalid_job"           // argument of schedule cancel
+	MsgNoPreviousDir       MessageID = "no_previous_dir"       // cd - before the first cd
+)
+
+// Messages maps message IDs to translations.
+type Messages map[MessageID]string
+
+var locales = map[string]Messages{
+	"en": {
+		MsgAvailableCommands:   "Available commands:",
+		MsgExitConsole:         "Exit the console",
+		MsgNamespaceCommands:   "Commands of %s:",
+		MsgAborted:             "Aborted",
+		MsgErrorReadingLine:    "Error reading line: %s",
+		MsgErrorRunningCmd:     "error running command %s: %s",
+		MsgCmdPanicked:         "command %s panicked: %v",
+		MsgCmdDone:             "%sdone",
+		MsgCmdFailed:           "%serror: %s",
+		MsgDangerousCmd:        "%s is a dangerous command (%s)",
+		MsgConfirmYesNo:        "Do you really want to run %s? [yes/no]: ",
+		MsgConfirmYes:          "yes",
+		MsgConfirmType:         "Type %q to confirm: ",
+		MsgUsername:            "Username: ",
+		MsgPassword:            "Password: ",
+		MsgNothingToUndo:       "Nothing to undo",
+		MsgUndoStack:           "Undo stack:",
+		MsgUndoing:             "Undoing %s",
+		MsgHelpDescription:     "Show the help",
+		MsgClearDescription:    "Clear the screen",
+		MsgUndoDescription:     "Undo the last operation",
+		MsgStatsDescription:    "Show command statistics",
+		MsgCalcDescription:     "Evaluate an arithmetic expression",
+		MsgQuitDescription:     "Quit the console",
+		MsgEnableDescription:   "Elevate privileges",
+		MsgDisableDescription:  "Drop elevated privileges",
+		MsgSudoDescription:     "Run a command with elevated privileges",
+		MsgModeExitDescription: "Leave the current mode",
+		MsgVersionDescription:  "Show the version",
+		MsgCdDescription:       "Change the working directory",
+		MsgPwdDescription:      "Print the working directory",
+		MsgExecDescription:     "Run an external command",
+		MsgEveryDescription:    "Run a command periodically",
+		MsgAtDescription:       "Run a command at a given time",
+		MsgScheduleDescription: "List or cancel scheduled commands",
+		MsgJobScheduled:        "Scheduled job #%d",
+		MsgNoJobs:              "No scheduled jobs",
+		MsgFormatDescription:   "Show or set the output format",
+		MsgNoMatches:           "No commands matching %q",
+		MsgMore:                "--More-- (enter to continue, q to quit) ",
+		MsgManName:             "NAME",
+		MsgManAliases:          "ALIASES",
+		MsgManUsage:            "USAGE",
+		MsgManDescription:      "DESCRIPTION",
+		MsgManExamples:         "EXAMPLES",
+		MsgManSeeAlso:          "SEE ALSO",
+		MsgDryRunDescription:   "Show or toggle dry-run",
+		MsgDryRunState:         "dry-run is %s",
+		MsgWouldRun:            "dry-run: would run %s",
+		MsgCommandsDescription: "List the commands, --json for all details",
+		MsgUsageError:          "usage: %s",
+		MsgUsage:               "Usage: %s",
+		MsgUsageArguments:      "Arguments:",
+		MsgUsageFlags:          "Flags:",
+		MsgOneOf:               "(one of %s)",
+		MsgDefault:             "(default %s)",
+		MsgRequired:            "(required)",
+		MsgNoStats:             "No commands executed yet",
+		MsgStatsCommand:        "COMMAND",
+		MsgStatsCalls:          "CALLS",
+		MsgStatsErrors:         "ERRORS",
+		MsgStatsAvg:            "AVG",
+		MsgStatsMax:            "MAX",
+		MsgNamespaceDesc:       "%s commands",
+		MsgUnknownNamespaceCmd: "unknown command %s %s",
+		MsgInvalidJob:          "invalid job %q",
+		MsgNoPreviousDir:       "no previous directory",
+	},
+	"de": {
+		MsgAvailableCommands:   "Verfügbare Befehle:",
+		MsgExitConsole:         "Konsole beenden",
+		MsgNamespaceCommands:   "Befehle von %s:",
+		MsgAborted:             "Abgebrochen",
+		MsgErrorReadingLine:    "Fehler beim Lesen der Zeile: %s",
+		MsgErrorRunningCmd:     "Fehler beim Ausführen von %s: %s",
+		MsgCmdPanicked:         "Befehl %s ist abgestürzt: %v",
+		MsgCmdDone:             "%sfertig",
+		MsgCmdFailed:           "%sFehler: %s",
+		MsgDangerousCmd:        "%s ist ein gefährlicher Befehl (%s)",
+		MsgConfirmYesNo:        "Soll %s wirklich ausgeführt werden? [ja/nein]: ",
+		MsgConfirmYes:          "ja",
+		MsgConfirmType:         "Zur Bestätigung %q eingeben: ",
+		MsgUsername:            "Benutzername: ",
+		MsgPassword:            "Passwort: ",
+		MsgNothingToUndo:       "Nichts rückgängig zu machen",
+		MsgUndoStack:           "Rückgängig machen:",
+		MsgUndoing:             "Mache %s rückgängig",
+		MsgHelpDescription:     "Hilfe anzeigen",
+		MsgClearDescription:    "Bildschirm leeren",
+		MsgUndoDescription:     "Letzte Aktion rückgängig machen",
+		MsgStatsDescription:    "Befehlsstatistik anzeigen",
+		MsgCalcDescription:     "Arithmetischen Ausdruck auswerten",
+		MsgQuitDescription:     "Konsole beenden",
+		MsgEnableDescription:   "Rechte erhöhen",
+		MsgDisableDescription:  "Erhöhte Rechte abgeben",
+		MsgSudoDescription:     "Befehl mit erhöhten Rechten ausführen",
+		MsgModeExitDescription: "Aktuellen Modus verlassen",
+		MsgVersionDescription:  "Version anzeigen",
+		MsgCdDescription:       "Arbeitsverzeichnis wechseln",
+		MsgPwdDescription:      "Arbeitsverzeichnis anzeigen",
+		MsgExecDescription:     "Externen Befehl ausführen",
+		MsgEveryDescription:    "Befehl regelmäßig ausführen",
+		MsgAtDescription:       "Befehl zu einer bestimmten Zeit ausführen",
+		MsgScheduleDescription: "Geplante Befehle anzeigen oder abbrechen",
+		MsgJobScheduled:        "Auftrag #%d geplant",
+		MsgNoJobs:              "Keine geplanten Aufträge",
+		MsgFormatDescription:   "Ausgabeformat anzeigen oder festlegen",
+		MsgNoMatches:           "Keine Befehle zu %q gefunden",
+		MsgMore:                "--Mehr-- (Enter für weiter, q zum Beenden) ",
+		MsgManName:             "NAME",
+		MsgManAliases:          "ALIASE",
+		MsgManUsage:            "AUFRUF",
+		MsgManDescription:      "BESCHREIBUNG",
+		MsgManExamples:         "BEISPIELE",
+		MsgManSeeAlso:          "SIEHE AUCH",
+		MsgDryRunDescription:   "Probelauf anzeigen oder umschalten",
+		MsgDryRunState:         "Probelauf ist %s",
+		MsgWouldRun:            "Probelauf: würde %s ausführen",
+		MsgCommandsDescription: "Befehle auflisten, --json für alle Details",
+		MsgUsageError:          "Aufruf: %s",
+		MsgUsage:               "Aufruf: %s",
+		MsgUsageArguments:      "Argumente:",
+		MsgUsageFlags:          "Optionen:",
+		MsgOneOf:               "(eines von %s)",
+		MsgDefault:             "(Standard %s)",
+		MsgRequired:            "(erforderlich)",
+		MsgNoStats:             "Noch keine Befehle ausgeführt",
+		MsgStatsCommand:        "BEFEHL",
+		MsgStatsCalls:          "AUFRUFE",
+		MsgStatsErrors:         "FEHLER",
+		MsgStatsAvg:            "MITTEL",
+		MsgStatsMax:            "MAX",
+		MsgNamespaceDesc:       "%s-Befehle",
+		MsgUnknownNamespaceCmd: "unbekannter Befehl %s %s",
+		MsgInvalidJob:          "ungültiger Auftrag %q",
+		MsgNoPreviousDir:       "kein vorheriges Verzeichnis",
+	},
+}
+
+// Locales returns the tags of the built-in translations.
+func Locales() []string {
+	return sortedKeys(locales)
+}
+
+// WithLocale selects a built-in translation, see Locales.
+// Messages set with WithMessages take precedence.
+func WithLocale(tag string) Opts {
+	return func(c *Console) {
+		m, ok := locales[tag]
+		if !ok {
+			c.optErr = fmt.Errorf("unknown locale %s", tag)
+			return
+		}
+		c.locale = m
+	}
+}
+
+// WithMessages overrides built-in messages. Missing messages fall back
+// to the locale and then to English.
+func WithMessages(m Messages) Opts {
+	return func(c *Console) {
+		if c.messages == nil {
+			c.messages = make(Messages)
+		}
+		for id, s := range m {
+			c.messages[id] = s
+		}
+	}
+}
+
+// msg returns the translation of a message.
+func (c *Console) msg(id MessageID, a ...any) string {
+	s, ok := c.messages[id]
+	if !ok {
+		if s, ok = c.locale[id]; !ok {
+			s = locales["en"][id]
+		}
+	}
+	if len(a) == 0 {
+		return s
+	}
+	return fmt.Sprintf(s, a...)
+}
+
+// description returns the translated description of builtins.
+// The description of namespaces takes their name.
+func (c *Console) description(cmd *Cmd) string {
+	if cmd.descID != "" && cmd.subs != nil {
+		return c.msg(cmd.descID, cmd.Name)
+	}
+	if cmd.descID != "" {
+		return c.msg(cmd.descID)
+	}
+	return cmd.Description
+}
@@ -0,0 +1,71 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// methodPrefix is the prefix of methods turned into commands by RegisterMethods.
+const methodPrefix = "Cmd"
+
+// CmdDocumenter provides the descriptions of commands registered with
+// RegisterMethods, keyed by command name.
+type CmdDocumenter interface {
+	CmdDocs() map[string]string
+}
+
+// RegisterMethods registers a command for every exported method of
+// receiver declared as
+//
+//	func (s *Svc) CmdListUsers(ctx context.Context, args []string) error
+//
+// The command name is the kebab-cased method name without the Cmd
+// prefix, list-users in this example. The descriptions are taken from
+// CmdDocs if receiver implements CmdDocumenter.
+func (c *Console) RegisterMethods(receiver any) error {
+	cmds, err := MethodCmds(receiver)
+	if err != nil {
+		return err
+	}
+	return c.RegisterCommands(cmds...)
+}
+
+// MethodCmds returns the commands RegisterMethods would register for receiver.
+func MethodCmds(receiver any) ([]*Cmd, error) {
+	rv := reflect.ValueOf(receiver)
+	if !rv.IsValid() || (rv.Kind() == reflect.Pointer && rv.IsNil()) {
+		return nil, errors.New("receiver must not be nil")
+	}
+	var docs map[string]string
+	if d, ok := receiver.(CmdDocumenter); ok {
+		docs = d.CmdDocs()
+	}
+	var cmds []*Cmd
+	t := rv.Type()
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+		if !strings.HasPrefix(m.Name, methodPrefix) || len(m.Name) == len(methodPrefix) {
+			continue
+		}
+		fn, ok := rv.Method(i).Interface().(func(context.Context, []string) error)
+		if !ok {
+			continue
+		}
+		name := kebabCase(strings.TrimPrefix(m.Name, methodPrefix))
+		cmds = append(cmds, &Cmd{
+			Name:        name,
+			Description: docs[name],
+			ContextHandler: func(ctx context.Context, _ *Console, args []string) error {
+				return fn(ctx, args)
+			},
+		})
+	}
+	if len(cmds) == 0 {
+		return nil, errors.New("receiver has no command methods")
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds, nil
+}
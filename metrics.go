@@ -0,0 +1,191 @@
+package console
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds of the duration histogram buckets.
+var DefaultBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Metrics receives an observation for every executed command.
+type Metrics interface {
+	ObserveCommand(name string, d time.Duration, err error)
+}
+
+// WithMetrics adds m to the metrics receiving command observations.
+// The console always keeps in-memory statistics for the stats command.
+func WithMetrics(m Metrics) Opts {
+	return func(c *Console) {
+		c.metrics = append(c.metrics, m)
+	}
+}
+
+// CmdStats holds the statistics of a single command.
+type CmdStats struct {
+	Name    string
+	Calls   uint64
+	Errors  uint64
+	Total   time.Duration
+	Max     time.Duration
+	Buckets []uint64 // counts per DefaultBuckets, plus one for +Inf
+}
+
+// Avg returns the average duration of a command execution.
+func (s CmdStats) Avg() time.Duration {
+	if s.Calls == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Calls)
+}
+
+// Stats collects per-command statistics in memory.
+type Stats struct {
+	mu   sync.Mutex
+	cmds map[string]*CmdStats
+}
+
+func NewStats() *Stats {
+	return &Stats{cmds: make(map[string]*CmdStats)}
+}
+
+func (s *Stats) ObserveCommand(name string, d time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cs, ok := s.cmds[name]
+	if !ok {
+		cs = &CmdStats{Name: name, Buckets: make([]uint64, len(DefaultBuckets)+1)}
+		s.cmds[name] = cs
+	}
+	cs.Calls++
+	if err != nil {
+		cs.Errors++
+	}
+	cs.Total += d
+	if d > cs.Max {
+		cs.Max = d
+	}
+	cs.Buckets[bucketIndex(d)]++
+}
+
+// Snapshot returns the statistics of all commands, ordered by number of calls.
+func (s *Stats) Snapshot() []CmdStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := make([]CmdStats, 0, len(s.cmds))
+	for _, cs := range s.cmds {
+		c := *cs
+		c.Buckets = append([]uint64(nil), cs.Buckets...)
+		stats = append(stats, c)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Calls != stats[j].Calls {
+			return stats[i].Calls > stats[j].Calls
+		}
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+func bucketIndex(d time.Duration) int {
+	for i, b := range DefaultBuckets {
+		if d <= b {
+			return i
+		}
+	}
+	return len(DefaultBuckets)
+}
+
+// Stats returns the in-memory command statistics.
+func (c *Console) Stats() *Stats {
+	return c.stats
+}
+
+func (c *Console) observe(cmd *Cmd, d time.Duration, err error) {
+	c.stats.ObserveCommand(cmd.Name, d, err)
+	for _, m := range c.metrics {
+		m.ObserveCommand(cmd.Name, d, err)
+	}
+}
+
+// ExpvarMetrics publishes command metrics as expvar map.
+type ExpvarMetrics struct {
+	calls    *expvar.Map
+	errors   *expvar.Map
+	duration *expvar.Map
+}
+
+// NewExpvarMetrics publishes the metrics under the given name.
+// It panics if the name is already in use, like expvar.Publish.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	m := &ExpvarMetrics{
+		calls:    new(expvar.Map),
+		errors:   new(expvar.Map),
+		duration: new(expvar.Map),
+	}
+	root := expvar.NewMap(name)
+	root.Set("calls", m.calls)
+	root.Set("errors", m.errors)
+	root.Set("duration_buckets", m.duration)
+	return m
+}
+
+func (m *ExpvarMetrics) ObserveCommand(name string, d time.Duration, err error) {
+	m.calls.Add(name, 1)
+	if err != nil {
+		m.errors.Add(name, 1)
+	}
+	hv := m.duration.Get(name)
+	if hv == nil {
+		h := new(expvar.Map)
+		m.duration.Set(name, h)
+		hv = h
+	}
+	h := hv.(*expvar.Map)
+	h.Add(bucketLabel(bucketIndex(d)), 1)
+}
+
+func bucketLabel(i int) string {
+	if i == len(DefaultBuckets) {
+		return "+Inf"
+	}
+	return DefaultBuckets[i].String()
+}
+
+func statsView(c *Console) string {
+	stats := c.stats.Snapshot()
+	if len(stats) == 0 {
+		return c.msg(MsgNoStats)
+	}
+	s := fmt.Sprintf("%-20s %8s %8s %12s %12s", c.msg(MsgStatsCommand), c.msg(MsgStatsCalls),
+		c.msg(MsgStatsErrors), c.msg(MsgStatsAvg), c.msg(MsgStatsMax))
+	for _, cs := range stats {
+		s += fmt.Sprintf("\n%-20s %8d %8d %12s %12s", cs.Name, cs.Calls, cs.Errors, cs.Avg(), cs.Max)
+	}
+	return s
+}
+
+var statsCmd = &Cmd{
+	Name:        "stats",
+	Description: "Show command statistics",
+	descID:      MsgStatsDescription,
+	builtin:     true,
+	ContextHandler: func(ctx context.Context, c *Console, args []string) error {
+		fmt.Fprintln(c.Writer(ctx), statsView(c))
+		return nil
+	},
+}
@@ -0,0 +1,123 @@
+package console
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrUnknownMode = errors.New("unknown mode")
+	ErrNoMode      = errors.New("no mode active")
+)
+
+// Mode is a set of commands only available after entering the mode,
+// like the configuration mode of a router. The commands of the console
+// remain available and are shadowed by commands of the mode with the same name.
+type Mode struct {
+	Name string
+	// Prompt is inserted into the prompt while the mode is active, before its
+	// trailing symbols: router> becomes router(config)>. Defaults to (Name).
+	Prompt string
+	Cmds   []*Cmd
+	// ExitCmd leaves the mode. Defaults to exit.
+	ExitCmd *Cmd
+}
+
+var modeExitCmd = &Cmd{
+	Name:        "exit",
+	Description: "Leave the current mode",
+	descID:      MsgModeExitDescription,
+	IgnorePipe:  true,
+	Handler: func(c *Console, args []string) error {
+		return c.ExitMode()
+	},
+}
+
+// RegisterMode registers a mode which can be entered with EnterMode.
+func (c *Console) RegisterMode(m *Mode) error {
+	if m.Name == "" {
+		return errors.New("mode has no name")
+	}
+	if _, ok := c.modes[m.Name]; ok {
+		return fmt.Errorf("mode %s already registered", m.Name)
+	}
+	var r registration
+	for _, cmd := range m.Cmds {
+		r.check(cmd)
+	}
+	if err := r.err(); err != nil {
+		return fmt.Errorf("mode %s: %w", m.Name, err)
+	}
+	for _, cmd := range m.Cmds {
+		cmd.Console = c
+	}
+	if m.ExitCmd == nil {
+		m.ExitCmd = modeExitCmd
+	}
+	if m.Prompt == "" {
+		m.Prompt = "(" + m.Name + ")"
+	}
+	if c.modes == nil {
+		c.modes = make(map[string]*Mode)
+	}
+	c.modes[m.Name] = m
+	return nil
+}
+
+// EnterMode enters a registered mode. Modes can be nested, the commands
+// of the mode entered last are available until it's left.
+func (c *Console) EnterMode(name string) error {
+	m, ok := c.modes[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownMode, name)
+	}
+	c.modeStack = append(c.modeStack, m)
+	return nil
+}
+
+// ExitMode leaves the mode entered last.
+func (c *Console) ExitMode() error {
+	if len(c.modeStack) == 0 {
+		return ErrNoMode
+	}
+	c.modeStack = c.modeStack[:len(c.modeStack)-1]
+	return nil
+}
+
+// Mode returns the name of the mode entered last or an empty string.
+func (c *Console) Mode() string {
+	if m := c.mode(); m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (c *Console) mode() *Mode {
+	if len(c.modeStack) == 0 {
+		return nil
+	}
+	return c.modeStack[len(c.modeStack)-1]
+}
+
+// modeCommands returns the commands of the current mode including its exit command.
+func (c *Console) modeCommands() []*Cmd {
+	m := c.mode()
+	if m == nil {
+		return nil
+	}
+	return append(append([]*Cmd(nil), m.Cmds...), m.ExitCmd)
+}
+
+// promptSuffix are the characters ending a prompt, like "> ".
+const promptSuffix = " >#$%:"
+
+// modePrompt inserts the prompt of the current mode into prompt.
+func (c *Console) modePrompt(prompt string) string {
+	m := c.mode()
+	if m == nil {
+		return prompt
+	}
+	i := len(strings.TrimRight(prompt, promptSuffix))
+	return prompt[:i] + m.Prompt + prompt[i:]
+}
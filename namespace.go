@@ -0,0 +1,132 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RegisterNamespace registers commands under the namespace ns. They're
+// run with the namespace as first word, like net show for the command
+// show in the namespace net. The name of each command is prefixed with
+// the namespace. Registering a namespace again adds to its commands.
+func (c *Console) RegisterNamespace(ns string, cmds ...*Cmd) error {
+	if ns == "" || strings.Contains(ns, " ") {
+		return fmt.Errorf("invalid namespace %q", ns)
+	}
+	parent := c.namespace(ns)
+	var subs []*Cmd
+	if parent != nil {
+		subs = parent.subs
+	}
+	r := registration{existing: subs, prefix: ns + " "}
+	for _, cmd := range cmds {
+		r.check(cmd)
+	}
+	if err := r.err(); err != nil {
+		return fmt.Errorf("namespace %s: %w", ns, err)
+	}
+	if parent == nil {
+		parent = newNamespace(ns)
+		if err := c.RegisterCommands(parent); err != nil {
+			return err
+		}
+	}
+	for _, cmd := range cmds {
+		cmd.Name = ns + " " + strings.TrimPrefix(cmd.Name, ns+" ")
+		cmd.Console = c
+		parent.subs = append(parent.subs, cmd)
+	}
+	return nil
+}
+
+// NamespaceCmd creates the command of the namespace ns with the commands
+// cmds without registering it, e.g. to be loaded by RegisterLazy. Errors
+// are returned once the command is registered or loaded.
+func NamespaceCmd(ns string, cmds ...*Cmd) *Cmd {
+	parent := newNamespace(ns)
+	if ns == "" || strings.Contains(ns, " ") {
+		parent.bindErr = fmt.Errorf("invalid namespace %q", ns)
+		return parent
+	}
+	r := registration{prefix: ns + " "}
+	for _, cmd := range cmds {
+		r.check(cmd)
+	}
+	if err := r.err(); err != nil {
+		parent.bindErr = fmt.Errorf("namespace %s: %w", ns, err)
+		return parent
+	}
+	for _, cmd := range cmds {
+		cmd.Name = ns + " " + strings.TrimPrefix(cmd.Name, ns+" ")
+		parent.subs = append(parent.subs, cmd)
+	}
+	return parent
+}
+
+func (c *Console) namespace(ns string) *Cmd {
+	for _, cmd := range c.cmds {
+		if cmd.subs != nil && cmd.Name == ns {
+			return cmd
+		}
+	}
+	return nil
+}
+
+func newNamespace(ns string) *Cmd {
+	parent := &Cmd{
+		Name:        ns,
+		Description: fmt.Sprintf("%s commands", ns),
+		descID:      MsgNamespaceDesc,
+		subs:        []*Cmd{},
+	}
+	// reached if no command of the namespace matches
+	parent.ContextHandler = func(ctx context.Context, c *Console, args []string) error {
+		if len(args) == 0 || args[0] == "" {
+			fmt.Fprintln(c.Writer(ctx), namespaceView(c, parent))
+			return nil
+		}
+		return errors.New(c.msg(MsgUnknownNamespaceCmd, ns, args[0]))
+	}
+	return parent
+}
+
+// sub returns the command of the namespace named name without the namespace.
+func (c *Cmd) sub(name string) *Cmd {
+	for _, s := range c.subs {
+		if s.Name == c.Name+" "+name {
+			return s
+		}
+		for _, a := range s.Aliases {
+			if a == name {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Cmd) completeSub(con *Console, prefix string) (s []string) {
+	for _, sub := range c.subs {
+		if !sub.permitted(con) {
+			continue
+		}
+		for _, n := range append([]string{strings.TrimPrefix(sub.Name, c.Name+" ")}, sub.Aliases...) {
+			if strings.HasPrefix(n, prefix) {
+				s = append(s, n)
+			}
+		}
+	}
+	return
+}
+
+func namespaceView(c *Console, ns *Cmd) string {
+	s := c.msg(MsgNamespaceCommands, ns.Name)
+	for _, sub := range ns.subs {
+		if sub.permitted(c) {
+			s += fmt.Sprintf("\n  %s - %s", sub.Name, c.description(sub))
+		}
+	}
+	return s
+}
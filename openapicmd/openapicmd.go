@@ -0,0 +1,402 @@
+// Package openapicmd turns the operations of an OpenAPI 3 document into console commands.
+//
+// Commands are named after the operation ID, listPets becomes list-pets, or
+// after the method and path if the operation has no ID. Parameters are set
+// with flags named like the parameter, a request body with --body, either as
+// JSON or as @file. JSON responses are printed indented.
+//
+// @file reads the file on the host of the console. Consoles served to remote
+// users, e.g. over SSH, should disable it with WithoutBodyFiles, as it
+// lets them send any JSON file the process can read to the API.
+package openapicmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jon4hz/console"
+)
+
+// Auth adds credentials to a request.
+type Auth func(req *http.Request)
+
+// BearerToken authenticates with an Authorization bearer header.
+func BearerToken(token string) Auth {
+	return func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// BasicAuth authenticates with HTTP basic authentication.
+func BasicAuth(user, password string) Auth {
+	return func(req *http.Request) {
+		req.SetBasicAuth(user, password)
+	}
+}
+
+// APIKey sets the API key in the header.
+func APIKey(header, key string) Auth {
+	return func(req *http.Request) {
+		req.Header.Set(header, key)
+	}
+}
+
+type client struct {
+	baseURL     string
+	http        *http.Client
+	auth        Auth
+	noBodyFiles bool
+}
+
+// Option configures the generated commands.
+type Option func(*client)
+
+// WithBaseURL overrides the first server URL of the document.
+func WithBaseURL(u string) Option {
+	return func(c *client) {
+		c.baseURL = u
+	}
+}
+
+// WithHTTPClient sets the client used for the requests.
+// Defaults to http.DefaultClient.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *client) {
+		c.http = hc
+	}
+}
+
+// WithAuth authenticates every request.
+func WithAuth(auth Auth) Option {
+	return func(c *client) {
+		c.auth = auth
+	}
+}
+
+// WithoutBodyFiles disables reading the request body from a file with --body @file.
+func WithoutBodyFiles() Option {
+	return func(c *client) {
+		c.noBodyFiles = true
+	}
+}
+
+// Commands returns a command for every operation of the document,
+// sorted by name.
+func (s *Spec) Commands(opts ...Option) ([]*console.Cmd, error) {
+	c := &client{http: http.DefaultClient}
+	if len(s.Servers) > 0 {
+		c.baseURL = s.Servers[0].URL
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.baseURL == "" {
+		return nil, errors.New("document has no server, use WithBaseURL")
+	}
+
+	var cmds []*console.Cmd
+	for path, item := range s.Paths {
+		for _, m := range methods {
+			op, err := s.operation(item, m)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(m), path, err)
+			}
+			if op != nil {
+				cmds = append(cmds, c.cmd(m, path, op))
+			}
+		}
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds, nil
+}
+
+func cmdName(method, path string, op *Operation) string {
+	if op.OperationID != "" {
+		return kebab(op.OperationID)
+	}
+	parts := []string{method}
+	for _, p := range strings.Split(path, "/") {
+		if p = strings.Trim(p, "{}"); p != "" {
+			parts = append(parts, kebab(p))
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+func (c *client) cmd(method, path string, op *Operation) *console.Cmd {
+	name := cmdName(method, path, op)
+	desc := op.Summary
+	if desc == "" {
+		desc = fmt.Sprintf("%s %s", strings.ToUpper(method), path)
+	}
+	return &console.Cmd{
+		Name:        name,
+		Description: desc,
+		// operations may have a parameter named output
+		ParsesOutputFlag: true,
+		Completer: func(_ *console.Console, args []string) []string {
+			return complete(op, args)
+		},
+		ContextHandler: func(ctx context.Context, con *console.Console, args []string) error {
+			values, body, err := c.parseFlags(op, args)
+			if errors.Is(err, errHelp) {
+				con.Println(c.usage(name, op))
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			req, err := c.request(ctx, method, path, op, values, body)
+			if err != nil {
+				return err
+			}
+			return c.do(con.Writer(ctx), req)
+		},
+	}
+}
+
+var errHelp = errors.New("help requested")
+
+// parseFlags returns the parameter values and the request body of args.
+func (c *client) parseFlags(op *Operation, args []string) (map[string][]string, []byte, error) {
+	values := make(map[string][]string)
+	var body []byte
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "" {
+			continue
+		}
+		if a == "--help" || a == "-h" {
+			return nil, nil, errHelp
+		}
+		if !strings.HasPrefix(a, "--") {
+			return nil, nil, fmt.Errorf("unexpected argument %s", a)
+		}
+		name, value, hasValue := strings.Cut(a[2:], "=")
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("flag %s needs a value", a)
+			}
+			i++
+			value = args[i]
+		}
+		if name == "body" {
+			b, err := c.readBody(value)
+			if err != nil {
+				return nil, nil, err
+			}
+			body = b
+			continue
+		}
+		p := param(op, name)
+		if p == nil {
+			return nil, nil, fmt.Errorf("unknown flag --%s", name)
+		}
+		if len(p.Schema.Enum) > 0 && !contains(p.Schema.Enum, value) {
+			return nil, nil, fmt.Errorf("invalid value %q for --%s, must be one of %s", value, name, strings.Join(p.Schema.Enum, ", "))
+		}
+		values[p.Name] = append(values[p.Name], value)
+	}
+	for _, p := range op.Parameters {
+		if _, ok := values[p.Name]; ok {
+			continue
+		}
+		if p.Schema.Default != "" {
+			values[p.Name] = []string{p.Schema.Default}
+		} else if p.Required || p.In == "path" {
+			return nil, nil, fmt.Errorf("flag --%s is required", p.Name)
+		}
+	}
+	if body == nil && op.RequestBody != nil && op.RequestBody.Required {
+		return nil, nil, errors.New("flag --body is required")
+	}
+	return values, body, nil
+}
+
+// readBody returns the body of --body, which is JSON or @file with JSON.
+func (c *client) readBody(value string) ([]byte, error) {
+	b := []byte(value)
+	if path, ok := strings.CutPrefix(value, "@"); ok {
+		if c.noBodyFiles {
+			return nil, errors.New("reading the body from a file is disabled")
+		}
+		var err error
+		if b, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if !json.Valid(b) {
+		return nil, errors.New("body is not valid JSON")
+	}
+	return b, nil
+}
+
+func param(op *Operation, name string) *Parameter {
+	for _, p := range op.Parameters {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+func (c *client) request(ctx context.Context, method, path string, op *Operation, values map[string][]string, body []byte) (*http.Request, error) {
+	query := url.Values{}
+	header := http.Header{}
+	for _, p := range op.Parameters {
+		v, ok := values[p.Name]
+		if !ok {
+			continue
+		}
+		switch p.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(v[0]))
+		case "query":
+			query[p.Name] = v
+		case "header":
+			header[http.CanonicalHeaderKey(p.Name)] = v
+		}
+	}
+	u := strings.TrimSuffix(c.baseURL, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(method), u, r)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.auth != nil {
+		c.auth(req)
+	}
+	return req, nil
+}
+
+func (c *client) do(w io.Writer, req *http.Request) error {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	var out bytes.Buffer
+	if json.Indent(&out, data, "", "  ") != nil {
+		out.Reset()
+		out.Write(data)
+	}
+	if !bytes.HasSuffix(out.Bytes(), []byte("\n")) {
+		out.WriteByte('\n')
+	}
+	_, err = w.Write(out.Bytes())
+	return err
+}
+
+func complete(op *Operation, args []string) []string {
+	cur := args[len(args)-1]
+	if n := len(args); n > 1 && strings.HasPrefix(args[n-2], "--") && !strings.Contains(args[n-2], "=") {
+		if p := param(op, args[n-2][2:]); p != nil {
+			return withPrefix(p.Schema.Enum, cur)
+		}
+		return nil
+	}
+	names := []string{"--body"}
+	for _, p := range op.Parameters {
+		names = append(names, "--"+p.Name)
+	}
+	sort.Strings(names)
+	return withPrefix(names, cur)
+}
+
+func (c *client) usage(name string, op *Operation) string {
+	s := "Usage: " + name + " [flags]"
+	if op.Description != "" {
+		s += "\n\n" + op.Description
+	}
+	s += "\n\nFlags:"
+	for _, p := range op.Parameters {
+		desc := p.Description
+		if len(p.Schema.Enum) > 0 {
+			desc += fmt.Sprintf(" (one of %s)", strings.Join(p.Schema.Enum, ", "))
+		}
+		if p.Schema.Default != "" {
+			desc += fmt.Sprintf(" (default %s)", p.Schema.Default)
+		}
+		if p.Required || p.In == "path" {
+			desc += " (required)"
+		}
+		s += fmt.Sprintf("\n  %-20s %s", "--"+p.Name, strings.TrimSpace(desc))
+	}
+	if op.RequestBody != nil {
+		desc := "request body as JSON or @file"
+		if c.noBodyFiles {
+			desc = "request body as JSON"
+		}
+		s += fmt.Sprintf("\n  %-20s %s", "--body", desc)
+	}
+	return s
+}
+
+func withPrefix(values []string, prefix string) (s []string) {
+	for _, v := range values {
+		if strings.HasPrefix(v, prefix) {
+			s = append(s, v)
+		}
+	}
+	return
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// kebab converts an identifier like listPets or list_pets to list-pets.
+func kebab(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			if i > 0 && !(s[i-1] >= 'A' && s[i-1] <= 'Z') && s[i-1] != '-' {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r + 'a' - 'A')
+		case r == '_' || r == ' ':
+			b.WriteByte('-')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,134 @@
+package openapicmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jon4hz/console"
+	"github.com/stretchr/testify/assert"
+)
+
+const spec = `
+openapi: 3.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      summary: List pets
+      parameters:
+        - $ref: "#/components/parameters/limit"
+        - name: kind
+          in: query
+          schema: {type: string, enum: [cat, dog]}
+    post:
+      operationId: createPet
+      requestBody:
+        required: true
+  /pets/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+    delete:
+      summary: Delete a pet
+components:
+  parameters:
+    limit:
+      name: limit
+      in: query
+      schema: {type: integer, default: 20}
+`
+
+func TestCommands(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.String()+" "+r.Header.Get("Authorization")+" "+string(body))
+		if r.Method == http.MethodDelete {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"name":"rex"}`))
+	}))
+	defer srv.Close()
+
+	s, err := Parse([]byte(spec))
+	assert.NoError(t, err)
+	_, err = s.Commands()
+	assert.Error(t, err)
+	cmds, err := s.Commands(WithBaseURL(srv.URL), WithAuth(BearerToken("secret")))
+	assert.NoError(t, err)
+	var names []string
+	for _, c := range cmds {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"create-pet", "delete-pets-id", "list-pets"}, names)
+
+	var out bytes.Buffer
+	c, err := console.New(console.WithOutput(&out))
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.RegisterCommands(cmds...))
+
+	assert.NoError(t, c.Run(context.Background(), "list-pets --kind dog"))
+	assert.Equal(t, "{\n  \"name\": \"rex\"\n}\n", out.String())
+	assert.Error(t, c.Run(context.Background(), "list-pets --kind bird"))
+	assert.NoError(t, c.Run(context.Background(), `create-pet --body {"name":"rex"}`))
+	assert.Error(t, c.Run(context.Background(), "create-pet"))
+	assert.EqualError(t, c.Run(context.Background(), "delete-pets-id --id 7"), "404 Not Found: not found")
+	assert.Equal(t, []string{
+		"GET /pets?kind=dog&limit=20 Bearer secret ",
+		`POST /pets Bearer secret {"name":"rex"}`,
+		"DELETE /pets/7 Bearer secret ",
+	}, requests)
+
+	assert.Equal(t, []string{"--kind", "--limit"}, cmds[2].Completer(c, []string{"--"})[1:])
+	assert.Equal(t, []string{"cat"}, cmds[2].Completer(c, []string{"--kind", "c"}))
+}
+
+func TestBodyFiles(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	pet, secret := filepath.Join(dir, "pet.json"), filepath.Join(dir, "secret")
+	assert.NoError(t, os.WriteFile(pet, []byte(`{"name":"rex"}`), 0o600))
+	assert.NoError(t, os.WriteFile(secret, []byte("root:x:0:0"), 0o600))
+
+	s, err := Parse([]byte(spec))
+	assert.NoError(t, err)
+	for _, noFiles := range []bool{false, true} {
+		opts := []Option{WithBaseURL(srv.URL)}
+		if noFiles {
+			opts = append(opts, WithoutBodyFiles())
+		}
+		cmds, err := s.Commands(opts...)
+		assert.NoError(t, err)
+		c, err := console.New(console.WithOutput(io.Discard))
+		assert.NoError(t, err)
+		assert.NoError(t, c.RegisterCommands(cmds...))
+
+		err = c.Run(context.Background(), "create-pet --body @"+pet)
+		want := "body is not valid JSON"
+		if noFiles {
+			want = "reading the body from a file is disabled"
+			assert.EqualError(t, err, want)
+		} else {
+			assert.NoError(t, err)
+		}
+		// files are validated like inline bodies
+		assert.EqualError(t, c.Run(context.Background(), "create-pet --body @"+secret), want)
+		c.Close()
+	}
+	assert.Equal(t, []string{`{"name":"rex"}`}, bodies)
+}
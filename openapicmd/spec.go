@@ -0,0 +1,99 @@
+package openapicmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is the part of an OpenAPI 3 document needed to call its operations.
+type Spec struct {
+	Servers []struct {
+		URL string `yaml:"url"`
+	} `yaml:"servers"`
+	Paths      map[string]PathItem `yaml:"paths"`
+	Components struct {
+		Parameters map[string]*Parameter `yaml:"parameters"`
+	} `yaml:"components"`
+}
+
+// PathItem holds the operations of a path by lower case HTTP method.
+type PathItem map[string]yaml.Node
+
+// Operation is a single API operation.
+type Operation struct {
+	OperationID string       `yaml:"operationId"`
+	Summary     string       `yaml:"summary"`
+	Description string       `yaml:"description"`
+	Parameters  []*Parameter `yaml:"parameters"`
+	RequestBody *struct {
+		Required bool `yaml:"required"`
+	} `yaml:"requestBody"`
+}
+
+// Parameter is a path, query or header parameter.
+type Parameter struct {
+	Ref         string `yaml:"$ref"`
+	Name        string `yaml:"name"`
+	In          string `yaml:"in"`
+	Description string `yaml:"description"`
+	Required    bool   `yaml:"required"`
+	Schema      struct {
+		Type    string   `yaml:"type"`
+		Enum    []string `yaml:"enum"`
+		Default string   `yaml:"default"`
+	} `yaml:"schema"`
+}
+
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Load reads an OpenAPI document in YAML or JSON format.
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse parses an OpenAPI document in YAML or JSON format.
+func Parse(data []byte) (*Spec, error) {
+	var s Spec
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	return &s, nil
+}
+
+// operation returns the operation of the HTTP method with the
+// parameters shared by all operations of the path.
+func (s *Spec) operation(item PathItem, method string) (*Operation, error) {
+	node, ok := item[method]
+	if !ok {
+		return nil, nil
+	}
+	var op Operation
+	if err := node.Decode(&op); err != nil {
+		return nil, err
+	}
+	if shared, ok := item["parameters"]; ok {
+		var params []*Parameter
+		if err := shared.Decode(&params); err != nil {
+			return nil, err
+		}
+		op.Parameters = append(params, op.Parameters...)
+	}
+	for i, p := range op.Parameters {
+		if p.Ref == "" {
+			continue
+		}
+		name, ok := strings.CutPrefix(p.Ref, "#/components/parameters/")
+		if !ok || s.Components.Parameters[name] == nil {
+			return nil, fmt.Errorf("unresolvable reference %s", p.Ref)
+		}
+		op.Parameters[i] = s.Components.Parameters[name]
+	}
+	return &op, nil
+}
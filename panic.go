@@ -0,0 +1,42 @@
+package console
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is returned if a command handler panicked.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// WithRepanic re-raises panics of command handlers instead of recovering them.
+// This is useful in tests.
+func WithRepanic(repanic bool) Opts {
+	return func(c *Console) {
+		c.repanic = repanic
+	}
+}
+
+func (c *Console) safeHandle(ctx context.Context, cmd *Cmd, args []string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if c.repanic {
+				panic(r)
+			}
+			perr := &PanicError{Value: r, Stack: debug.Stack()}
+			c.logger.Error("command panicked", "command", cmd.Name, "panic", r)
+			w := c.Writer(ctx)
+			fmt.Fprintln(w, c.theme.Error.Render(c.msg(MsgCmdPanicked, cmd.Name, r)))
+			fmt.Fprintln(w, c.theme.Stack.Render(string(perr.Stack)))
+			err = perr
+		}
+	}()
+	return cmd.handle(ctx, c, args)
+}
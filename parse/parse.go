@@ -0,0 +1,129 @@
+// Package parse splits console input into the command name and its arguments.
+//
+// Words are separated by spaces or tabs. A word starting with a single or
+// double quote extends to the matching quote, so it may contain spaces.
+// Within double quotes, \" and \\ are escapes for a quote and a backslash.
+// Quotes within a word are kept, e.g. the word {"id":1} is passed as is.
+package parse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Invocation is a parsed input line.
+type Invocation struct {
+	// Name is the first word, the name of the command.
+	Name string
+	Args []string
+}
+
+// Error reports invalid input.
+type Error struct {
+	// Pos is the byte offset in the input.
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("parse error at %d: %s", e.Pos, e.Msg)
+}
+
+// Parse splits line into words. An empty line parses to an empty Invocation.
+func Parse(line string) (Invocation, error) {
+	words, err := Split(line)
+	if err != nil || len(words) == 0 {
+		return Invocation{}, err
+	}
+	return Invocation{Name: words[0], Args: words[1:]}, nil
+}
+
+// Split splits line into words, see the package documentation.
+func Split(line string) ([]string, error) {
+	var words []string
+	for i := 0; i < len(line); {
+		if isSpace(line[i]) {
+			i++
+			continue
+		}
+		var (
+			w   string
+			err error
+		)
+		if q := line[i]; q == '"' || q == '\'' {
+			w, i, err = quoted(line, i)
+			if err != nil {
+				return nil, err
+			}
+			if i < len(line) && !isSpace(line[i]) {
+				return nil, &Error{Pos: i, Msg: "closing quote must be followed by a space"}
+			}
+		} else {
+			start := i
+			for i < len(line) && !isSpace(line[i]) {
+				i++
+			}
+			w = line[start:i]
+		}
+		words = append(words, w)
+	}
+	return words, nil
+}
+
+// quoted returns the word quoted at line[start] and the offset following the closing quote.
+func quoted(line string, start int) (string, int, error) {
+	q := line[start]
+	var b strings.Builder
+	for i := start + 1; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == q:
+			return b.String(), i + 1, nil
+		case c == '\\' && q == '"' && i+1 < len(line) && (line[i+1] == '"' || line[i+1] == '\\'):
+			i++
+			b.WriteByte(line[i])
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, &Error{Pos: start, Msg: "unterminated quote"}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+// Quote returns s as a word Split returns unchanged.
+func Quote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t") && s[0] != '"' && s[0] != '\'' {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// Join quotes the words and joins them with spaces.
+func Join(words []string) string {
+	q := make([]string, len(words))
+	for i, w := range words {
+		q[i] = Quote(w)
+	}
+	return strings.Join(q, " ")
+}
+
+// Expand replaces the first word of line if it's an alias. The rest of line
+// is appended to the expansion, e.g. the alias "ll" for "list --long"
+// expands "ll /tmp" to "list --long /tmp".
+func Expand(line string, aliases map[string]string) string {
+	start := 0
+	for start < len(line) && isSpace(line[start]) {
+		start++
+	}
+	end := start
+	for end < len(line) && !isSpace(line[end]) {
+		end++
+	}
+	if v, ok := aliases[line[start:end]]; ok {
+		return v + line[end:]
+	}
+	return line
+}
@@ -0,0 +1,72 @@
+package parse
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for line, want := range map[string]Invocation{
+		"":                        {},
+		"help":                    {Name: "help", Args: []string{}},
+		"  echo   a\tb ":          {Name: "echo", Args: []string{"a", "b"}},
+		`echo "a b" 'c "d"'`:      {Name: "echo", Args: []string{"a b", `c "d"`}},
+		`echo "say \"hi\" \\ \n"`: {Name: "echo", Args: []string{`say "hi" \ \n`}},
+		`create --body {"id":1}`:  {Name: "create", Args: []string{"--body", `{"id":1}`}},
+		`echo '' ""`:              {Name: "echo", Args: []string{"", ""}},
+		`set name=O'Brien`:        {Name: "set", Args: []string{"name=O'Brien"}},
+	} {
+		got, err := Parse(line)
+		assert.NoError(t, err, line)
+		assert.Equal(t, want, got, line)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for line, pos := range map[string]int{
+		`echo "a b`: 5,
+		`echo 'a`:   5,
+		`echo "a"b`: 8,
+	} {
+		_, err := Parse(line)
+		var perr *Error
+		if assert.True(t, errors.As(err, &perr), line) {
+			assert.Equal(t, pos, perr.Pos, line)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	aliases := map[string]string{"ll": "list --long"}
+	assert.Equal(t, "list --long /tmp", Expand("ll /tmp", aliases))
+	assert.Equal(t, "list --long", Expand("ll", aliases))
+	assert.Equal(t, "lll", Expand("lll", aliases))
+	assert.Equal(t, "list --long\t/tmp", Expand("ll\t/tmp", aliases))
+	assert.Equal(t, "list --long /tmp", Expand("  ll /tmp", aliases))
+}
+
+func FuzzSplit(f *testing.F) {
+	for _, s := range []string{"", "echo a b", `echo "a b" 'c'`, `x "\"\\"`, `a "b`, "\t'\"'"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		words, err := Split(line)
+		if err != nil {
+			return
+		}
+		again, err := Split(Join(words))
+		if err != nil {
+			t.Fatalf("joined words of %q don't parse: %s", line, err)
+		}
+		if len(words) != len(again) {
+			t.Fatalf("%q: got %q after join, want %q", line, again, words)
+		}
+		for i := range words {
+			if words[i] != again[i] {
+				t.Fatalf("%q: got %q after join, want %q", line, again, words)
+			}
+		}
+	})
+}
@@ -0,0 +1,36 @@
+//go:build linux
+
+package console
+
+import (
+	"net"
+	"os/user"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// peerUser returns the name of the user of the process connected to conn,
+// read from SO_PEERCRED. The uid is returned if it has no name.
+func peerUser(conn net.Conn) (string, bool) {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return "", false
+	}
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return "", false
+	}
+	var cred *unix.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil || credErr != nil {
+		return "", false
+	}
+	uid := strconv.FormatUint(uint64(cred.Uid), 10)
+	if u, err := user.LookupId(uid); err == nil {
+		return u.Username, true
+	}
+	return uid, true
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package console
+
+import "net"
+
+// peerUser returns false as the peer credentials are only read on Linux.
+func peerUser(conn net.Conn) (string, bool) {
+	return "", false
+}
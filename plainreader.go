@@ -0,0 +1,65 @@
+package console
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// PlainReader is a LineReader without line editing, completion or history navigation.
+// It is used for connections not supporting a terminal, e.g. raw TCP or unix sockets.
+type PlainReader struct {
+	r       *bufio.Reader
+	w       io.Writer
+	history []string
+}
+
+// NewPlainReader reads lines from r and writes the prompts to w.
+func NewPlainReader(r io.Reader, w io.Writer) *PlainReader {
+	return &PlainReader{r: bufio.NewReader(r), w: w}
+}
+
+func (p *PlainReader) Prompt(prompt string) (string, error) {
+	if _, err := io.WriteString(p.w, prompt); err != nil {
+		return "", err
+	}
+	line, err := p.r.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// PasswordPrompt reads a line like Prompt. The input can't be hidden without a terminal.
+func (p *PlainReader) PasswordPrompt(prompt string) (string, error) {
+	return p.Prompt(prompt)
+}
+
+func (p *PlainReader) SetCompleter(f func(line string) []string) {}
+
+func (p *PlainReader) AppendHistory(item string) {
+	p.history = append(p.history, item)
+}
+
+func (p *PlainReader) ReadHistory(r io.Reader) (int, error) {
+	var n int
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		p.history = append(p.history, s.Text())
+		n++
+	}
+	return n, s.Err()
+}
+
+func (p *PlainReader) WriteHistory(w io.Writer) (int, error) {
+	for i, h := range p.history {
+		if _, err := io.WriteString(w, h+"\n"); err != nil {
+			return i, err
+		}
+	}
+	return len(p.history), nil
+}
+
+func (p *PlainReader) Close() error {
+	return nil
+}
@@ -0,0 +1,54 @@
+// Package prommetrics exports console command metrics to Prometheus.
+package prommetrics
+
+import (
+	"time"
+
+	"github.com/jon4hz/console"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics implements console.Metrics with a counter and a duration histogram per command.
+type Metrics struct {
+	calls    *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// New creates the metrics and registers them with reg.
+func New(reg prometheus.Registerer, namespace string) (*Metrics, error) {
+	buckets := make([]float64, len(console.DefaultBuckets))
+	for i, b := range console.DefaultBuckets {
+		buckets[i] = b.Seconds()
+	}
+	m := &Metrics{
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "console",
+			Name:      "commands_total",
+			Help:      "Number of executed console commands.",
+		}, []string{"command", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "console",
+			Name:      "command_duration_seconds",
+			Help:      "Duration of console command executions.",
+			Buckets:   buckets,
+		}, []string{"command"}),
+	}
+	if err := reg.Register(m.calls); err != nil {
+		return nil, err
+	}
+	if err := reg.Register(m.duration); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *Metrics) ObserveCommand(name string, d time.Duration, err error) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	m.calls.WithLabelValues(name, status).Inc()
+	m.duration.WithLabelValues(name).Observe(d.Seconds())
+}
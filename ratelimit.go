@@ -0,0 +1,71 @@
+package console
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimit allows at most Calls executions of a command within Per.
+// Like the cooldown of a command, it counts the executions of all sessions
+// of an Engine. Commands which aren't confirmed don't count.
+type RateLimit struct {
+	Calls int
+	Per   time.Duration
+}
+
+// RateLimitError is returned if a command was rejected by its rate limit or cooldown.
+type RateLimitError struct {
+	Cmd        string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s: %s can be run again in %s", ErrRateLimited, e.Cmd, e.RetryAfter.Round(time.Second))
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// allow checks the rate limit and cooldown of cmd and, if record is set,
+// records the execution if allowed. The executions are shared by all
+// sessions of the engine, so a limit can't be bypassed with a new session.
+func (e *Engine) allow(cmd *Cmd, now time.Time, record bool) error {
+	if cmd.Cooldown <= 0 && cmd.RateLimit == nil {
+		return nil
+	}
+	e.callsMu.Lock()
+	defer e.callsMu.Unlock()
+	calls := e.calls[cmd]
+	if n := len(calls); cmd.Cooldown > 0 && n > 0 {
+		if wait := calls[n-1].Add(cmd.Cooldown).Sub(now); wait > 0 {
+			return &RateLimitError{Cmd: cmd.Name, RetryAfter: wait}
+		}
+	}
+	if rl := cmd.RateLimit; rl != nil && rl.Calls > 0 {
+		// drop calls outside of the window
+		i := 0
+		for i < len(calls) && now.Sub(calls[i]) >= rl.Per {
+			i++
+		}
+		calls = calls[i:]
+		if len(calls) >= rl.Calls {
+			return &RateLimitError{Cmd: cmd.Name, RetryAfter: calls[len(calls)-rl.Calls].Add(rl.Per).Sub(now)}
+		}
+	}
+	if !record {
+		return nil
+	}
+	calls = append(calls, now)
+	if cmd.RateLimit == nil || cmd.RateLimit.Calls <= 0 {
+		calls = calls[len(calls)-1:]
+	}
+	if e.calls == nil {
+		e.calls = make(map[*Cmd][]time.Time)
+	}
+	e.calls[cmd] = calls
+	return nil
+}
@@ -0,0 +1,163 @@
+package console
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// WithRecording records the session as asciinema v2 cast to the given file.
+func WithRecording(path string) Opts {
+	return func(c *Console) {
+		c.recordingFile = path
+	}
+}
+
+type castHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// recorder tees the console output into an asciinema cast.
+type recorder struct {
+	mu    sync.Mutex
+	out   io.Writer
+	f     *os.File
+	w     *bufio.Writer
+	enc   *json.Encoder
+	start time.Time
+}
+
+func newRecorder(path string, out io.Writer) (*recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	width, height := 80, 24
+	if w, h, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+		width, height = w, h
+	}
+	r := &recorder{
+		out:   out,
+		f:     f,
+		w:     bufio.NewWriter(f),
+		start: time.Now(),
+	}
+	r.enc = json.NewEncoder(r.w)
+	if err := r.enc.Encode(castHeader{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: r.start.Unix(),
+		Env:       map[string]string{"TERM": os.Getenv("TERM"), "SHELL": os.Getenv("SHELL")},
+	}); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *recorder) event(kind, data string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enc.Encode([]any{time.Since(r.start).Seconds(), kind, data})
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	n, err := r.out.Write(p)
+	if err != nil {
+		return n, err
+	}
+	// the terminal translates newlines, the player doesn't
+	data := strings.ReplaceAll(strings.ReplaceAll(string(p[:n]), "\r\n", "\n"), "\n", "\r\n")
+	return n, r.event("o", data)
+}
+
+func (r *recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.w.Flush(); err != nil {
+		r.f.Close()
+		return err
+	}
+	return r.f.Close()
+}
+
+// recordInput records a line read from the prompt.
+// The line editor echoes directly to the terminal, so the prompt and the input are added as output too.
+func (c *Console) recordInput(prompt, in string) {
+	if c.recorder == nil {
+		return
+	}
+	if err := c.recorder.event("i", in+"\r"); err != nil {
+		c.logger.Error("error writing recording", "err", err)
+	}
+	if err := c.recorder.event("o", prompt+in+"\r\n"); err != nil {
+		c.logger.Error("error writing recording", "err", err)
+	}
+}
+
+// Replay plays back an asciinema v2 cast to stdout in real time.
+func Replay(path string) error {
+	return ReplayTo(os.Stdout, path, 1)
+}
+
+// ReplayTo plays back an asciinema v2 cast to w.
+// The speed factor scales the playback, e.g. 2 replays twice as fast.
+func ReplayTo(w io.Writer, path string, speed float64) error {
+	if speed <= 0 {
+		return errors.New("speed must be positive")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	var h castHeader
+	if err := dec.Decode(&h); err != nil {
+		return fmt.Errorf("error reading cast header: %w", err)
+	}
+	if h.Version != 2 {
+		return fmt.Errorf("unsupported cast version %d", h.Version)
+	}
+
+	start := time.Now()
+	for {
+		var ev []any
+		if err := dec.Decode(&ev); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("error reading cast event: %w", err)
+		}
+		if len(ev) != 3 {
+			return fmt.Errorf("invalid cast event: %v", ev)
+		}
+		ts, ok1 := ev[0].(float64)
+		kind, ok2 := ev[1].(string)
+		data, ok3 := ev[2].(string)
+		if !ok1 || !ok2 || !ok3 {
+			return fmt.Errorf("invalid cast event: %v", ev)
+		}
+		if kind != "o" {
+			continue
+		}
+		at := time.Duration(ts / speed * float64(time.Second))
+		time.Sleep(time.Until(start.Add(at)))
+		if _, err := io.WriteString(w, data); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package console
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+var (
+	// ErrCmdRegistered is wrapped by a ConflictError.
+	ErrCmdRegistered = errors.New("command matches an existing command")
+	// ErrInvalidCmdName is wrapped by a NameError.
+	ErrInvalidCmdName = errors.New("invalid command name")
+)
+
+// nameChars are the characters allowed in names besides letters and digits.
+const nameChars = "-_.:/+=?!@"
+
+// NameError reports an invalid name or alias of a command.
+type NameError struct {
+	Cmd    *Cmd
+	Name   string
+	Reason string
+}
+
+func (e *NameError) Error() string {
+	return fmt.Sprintf("command %q: %s %q %s", e.Cmd.Name, nameKind(e.Cmd, e.Name), e.Name, e.Reason)
+}
+
+func (e *NameError) Unwrap() error { return ErrInvalidCmdName }
+
+// ConflictError reports a name or alias of a new command which is already
+// used by the name or an alias of Existing.
+type ConflictError struct {
+	Cmd      *Cmd
+	Name     string
+	Existing *Cmd
+}
+
+func (e *ConflictError) Error() string {
+	with := fmt.Sprintf("command %q", e.Existing.Name)
+	if e.Name != e.Existing.Name {
+		with = "alias of " + with
+	}
+	return fmt.Sprintf("command %q: %s %q collides with %s", e.Cmd.Name, nameKind(e.Cmd, e.Name), e.Name, with)
+}
+
+func (e *ConflictError) Unwrap() error { return ErrCmdRegistered }
+
+func nameKind(cmd *Cmd, name string) string {
+	if name == cmd.Name {
+		return "name"
+	}
+	return "alias"
+}
+
+// RegistrationError collects all problems of registering commands.
+// Nothing is registered if one of the commands is rejected.
+type RegistrationError struct {
+	// Errs are NameErrors, ConflictErrors and errors of Bind.
+	Errs []error
+}
+
+func (e *RegistrationError) Error() string {
+	if len(e.Errs) == 1 {
+		return e.Errs[0].Error()
+	}
+	s := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		s[i] = err.Error()
+	}
+	return fmt.Sprintf("%d registration errors: %s", len(e.Errs), strings.Join(s, "; "))
+}
+
+func (e *RegistrationError) Unwrap() []error { return e.Errs }
+
+// Conflicts returns the names colliding with existing commands.
+func (e *RegistrationError) Conflicts() []*ConflictError {
+	var conflicts []*ConflictError
+	for _, err := range e.Errs {
+		if c, ok := err.(*ConflictError); ok {
+			conflicts = append(conflicts, c)
+		}
+	}
+	return conflicts
+}
+
+// registration checks new commands against the existing ones and each other.
+type registration struct {
+	existing []*Cmd
+	checked  []*Cmd
+	// prefix is trimmed from all names before they're checked, see RegisterNamespace.
+	prefix string
+	errs   []error
+}
+
+func (r *registration) check(cmd *Cmd) {
+	if cmd.bindErr != nil {
+		r.errs = append(r.errs, cmd.bindErr)
+	}
+	for _, n := range cmd.names() {
+		if reason := invalidName(strings.TrimPrefix(n, r.prefix)); reason != "" {
+			r.errs = append(r.errs, &NameError{Cmd: cmd, Name: n, Reason: reason})
+		} else if existing := r.owner(n); existing != nil {
+			r.errs = append(r.errs, &ConflictError{Cmd: cmd, Name: n, Existing: existing})
+		}
+	}
+	r.checked = append(r.checked, cmd)
+}
+
+// owner returns the existing command named or aliased name.
+func (r *registration) owner(name string) *Cmd {
+	name = strings.TrimPrefix(name, r.prefix)
+	for _, cmd := range append(slices.Clip(r.existing), r.checked...) {
+		for _, n := range cmd.names() {
+			if strings.TrimPrefix(n, r.prefix) == name {
+				return cmd
+			}
+		}
+	}
+	return nil
+}
+
+func (r *registration) err() error {
+	if len(r.errs) == 0 {
+		return nil
+	}
+	return &RegistrationError{Errs: r.errs}
+}
+
+// invalidName returns why name isn't valid or an empty string.
+func invalidName(name string) string {
+	if name == "" {
+		return "is empty"
+	}
+	for _, r := range name {
+		switch {
+		case unicode.IsSpace(r):
+			return "contains whitespace"
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(nameChars, r):
+			return fmt.Sprintf("contains %q", r)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,312 @@
+package console
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the presentation of a Result.
+type Format string
+
+const (
+	// FormatText prints the data with fmt, one element per line for slices.
+	FormatText Format = "text"
+	// FormatTable prints slices of structs or maps, structs and maps as aligned columns.
+	FormatTable Format = "table"
+	// FormatJSON prints the data as indented JSON.
+	FormatJSON Format = "json"
+	// FormatYAML prints the data as YAML.
+	FormatYAML Format = "yaml"
+)
+
+// Formats returns the names of the formats a console can render.
+func Formats() []string {
+	s := make([]string, 0, len(defaultRenderers))
+	for f := range defaultRenderers {
+		s = append(s, string(f))
+	}
+	sort.Strings(s)
+	return s
+}
+
+// Renderer writes data to w.
+type Renderer func(w io.Writer, data any) error
+
+// Result is the data returned by a ResultHandler. The console renders it
+// in its output format, see WithOutputFormat, instead of the handler
+// printing it, so the same command serves interactive users and scripts.
+type Result struct {
+	Data any
+	// Renderers replace the default renderer of a format.
+	Renderers map[Format]Renderer
+}
+
+// NewResult creates a Result rendering data with the default renderers.
+func NewResult(data any) *Result {
+	return &Result{Data: data}
+}
+
+// With sets the renderer of format f and returns r.
+func (r *Result) With(f Format, render Renderer) *Result {
+	if r.Renderers == nil {
+		r.Renderers = make(map[Format]Renderer)
+	}
+	r.Renderers[f] = render
+	return r
+}
+
+var defaultRenderers = map[Format]Renderer{
+	FormatText:  renderText,
+	FormatTable: renderTable,
+	FormatJSON:  renderJSON,
+	FormatYAML:  renderYAML,
+}
+
+// WithOutputFormat sets the format results are rendered in, e.g. FormatJSON
+// for consoles driven by other programs. By default, results are rendered
+// as tables, or as text if the input is piped. It can be changed with the
+// format builtin and for a single command with the --output flag.
+func WithOutputFormat(f Format) Opts {
+	return func(c *Console) {
+		if err := checkFormat(f); err != nil {
+			c.optErr = err
+			return
+		}
+		c.format = f
+	}
+}
+
+// SetOutputFormat changes the format of the session, see WithOutputFormat.
+func (c *Console) SetOutputFormat(f Format) error {
+	if err := checkFormat(f); err != nil {
+		return err
+	}
+	c.storeMu.Lock()
+	defer c.storeMu.Unlock()
+	c.format = f
+	return nil
+}
+
+// OutputFormat returns the format results of the command running with ctx
+// are rendered in. Handlers printing themselves can use it to match the
+// results of other commands.
+func (c *Console) OutputFormat(ctx context.Context) Format {
+	if f, ok := ctx.Value(formatKey{}).(Format); ok {
+		return f
+	}
+	c.storeMu.RLock()
+	f := c.format
+	c.storeMu.RUnlock()
+	switch {
+	case f != "":
+		return f
+	case c.isOsPipe:
+		return FormatText
+	}
+	return FormatTable
+}
+
+type formatKey struct{}
+
+func checkFormat(f Format) error {
+	if _, ok := defaultRenderers[f]; !ok {
+		return fmt.Errorf("unknown output format %q, must be one of %s", f, strings.Join(Formats(), ", "))
+	}
+	return nil
+}
+
+// outputFlag removes the --output flag from args and returns its format.
+// Arguments following -- are left as they are.
+func outputFlag(args []string) ([]string, Format, error) {
+	for i, a := range args {
+		if a == "--" {
+			break
+		}
+		v, ok := strings.CutPrefix(a, "--output=")
+		n := 1
+		if a == "--output" {
+			if i+1 >= len(args) {
+				return nil, "", errors.New("flag --output needs a value")
+			}
+			v, ok, n = args[i+1], true, 2
+		}
+		if !ok {
+			continue
+		}
+		if err := checkFormat(Format(v)); err != nil {
+			return nil, "", err
+		}
+		return append(args[:i:i], args[i+n:]...), Format(v), nil
+	}
+	return args, "", nil
+}
+
+// Render writes res in the output format to the output of the command
+// running with ctx and stores its data at ResultKey.
+func (c *Console) Render(ctx context.Context, res *Result) error {
+	c.Set(ResultKey, res.Data)
+	f := c.OutputFormat(ctx)
+	render := res.Renderers[f]
+	if render == nil {
+		render = defaultRenderers[f]
+	}
+	return render(c.Writer(ctx), res.Data)
+}
+
+func renderText(w io.Writer, data any) error {
+	v := reflect.ValueOf(data)
+	if k := v.Kind(); (k == reflect.Slice || k == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8 {
+		for i := 0; i < v.Len(); i++ {
+			if _, err := fmt.Fprintln(w, v.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	_, err := fmt.Fprintln(w, data)
+	return err
+}
+
+func renderJSON(w io.Writer, data any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
+
+func renderYAML(w io.Writer, data any) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(data); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// renderTable renders data as a table if it has columns and as text otherwise.
+func renderTable(w io.Writer, data any) error {
+	header, rows, ok := tableRows(reflect.ValueOf(data))
+	if !ok {
+		return renderText(w, data)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			if i > 0 {
+				fmt.Fprint(tw, "\t")
+			}
+			fmt.Fprint(tw, cell)
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+// tableRows returns the columns of slices of structs or maps, and of single
+// structs and maps as key value pairs.
+func tableRows(v reflect.Value) (header []string, rows [][]string, ok bool) {
+	v = reflect.Indirect(v)
+	switch v.Kind() {
+	case reflect.Struct:
+		for _, f := range reflect.VisibleFields(v.Type()) {
+			if f.IsExported() && !f.Anonymous {
+				rows = append(rows, []string{f.Name, fmt.Sprint(v.FieldByIndex(f.Index).Interface())})
+			}
+		}
+		return []string{"Field", "Value"}, rows, true
+	case reflect.Map:
+		for _, k := range sortedMapKeys(v) {
+			rows = append(rows, []string{fmt.Sprint(k.Interface()), fmt.Sprint(v.MapIndex(k).Interface())})
+		}
+		return []string{"Key", "Value"}, rows, true
+	case reflect.Slice, reflect.Array:
+	default:
+		return nil, nil, false
+	}
+
+	elem := v.Type().Elem()
+	if elem.Kind() == reflect.Pointer && elem.Elem().Kind() == reflect.Struct {
+		elem = elem.Elem()
+	}
+	switch elem.Kind() {
+	case reflect.Struct:
+		var fields []reflect.StructField
+		for _, f := range reflect.VisibleFields(elem) {
+			if f.IsExported() && !f.Anonymous {
+				fields = append(fields, f)
+				header = append(header, f.Name)
+			}
+		}
+		for i := 0; i < v.Len(); i++ {
+			e := reflect.Indirect(v.Index(i))
+			row := make([]string, len(fields))
+			for j, f := range fields {
+				if e.IsValid() {
+					row[j] = fmt.Sprint(e.FieldByIndex(f.Index).Interface())
+				}
+			}
+			rows = append(rows, row)
+		}
+		return header, rows, true
+	case reflect.Map:
+		if elem.Key().Kind() != reflect.String {
+			return nil, nil, false
+		}
+		seen := make(map[string]bool)
+		for i := 0; i < v.Len(); i++ {
+			for _, k := range v.Index(i).MapKeys() {
+				if !seen[k.String()] {
+					seen[k.String()] = true
+					header = append(header, k.String())
+				}
+			}
+		}
+		sort.Strings(header)
+		for i := 0; i < v.Len(); i++ {
+			e := v.Index(i)
+			row := make([]string, len(header))
+			for j, k := range header {
+				if val := e.MapIndex(reflect.ValueOf(k).Convert(elem.Key())); val.IsValid() {
+					row[j] = fmt.Sprint(val.Interface())
+				}
+			}
+			rows = append(rows, row)
+		}
+		return header, rows, true
+	}
+	return nil, nil, false
+}
+
+func sortedMapKeys(m reflect.Value) []reflect.Value {
+	keys := m.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+	})
+	return keys
+}
+
+var formatCmd = &Cmd{
+	Name:        "format",
+	Description: "Show or set the output format",
+	descID:      MsgFormatDescription,
+	builtin:     true,
+	Args:        []*Arg{{Name: "format", Values: Formats()}},
+	ContextHandler: func(ctx context.Context, c *Console, args []string) error {
+		switch len(args) {
+		case 0:
+			fmt.Fprintln(c.Writer(ctx), c.OutputFormat(ctx))
+			return nil
+		case 1:
+			return c.SetOutputFormat(Format(args[0]))
+		}
+		return errors.New(c.msg(MsgUsageError, "format ["+strings.Join(Formats(), "|")+"]"))
+	},
+}
@@ -0,0 +1,143 @@
+package rpcplugin
+
+import (
+	"context"
+	"io"
+	"net/rpc"
+	"sync"
+
+	"github.com/hashicorp/go-plugin"
+)
+
+// rpcPlugin implements plugin.Plugin for the net/rpc protocol.
+// Only the plugin process sets impl.
+type rpcPlugin struct {
+	impl Provider
+}
+
+func (p *rpcPlugin) Server(b *plugin.MuxBroker) (interface{}, error) {
+	return &RPCServer{impl: p.impl, broker: b, cancels: make(map[uint32]context.CancelFunc)}, nil
+}
+
+func (p *rpcPlugin) Client(b *plugin.MuxBroker, c *rpc.Client) (interface{}, error) {
+	return &rpcClient{client: c, broker: b}, nil
+}
+
+// CompleteRequest is the argument of RPCServer.Complete.
+type CompleteRequest struct {
+	Name string
+	Args []string
+}
+
+// RunRequest is the argument of RPCServer.Run. The output is written
+// to the broker stream ID, which also identifies the execution.
+type RunRequest struct {
+	ID   uint32
+	Name string
+	Args []string
+}
+
+// RPCServer runs in the plugin process and serves the Provider.
+// It's exported for net/rpc only.
+type RPCServer struct {
+	impl   Provider
+	broker *plugin.MuxBroker
+
+	mu      sync.Mutex
+	cancels map[uint32]context.CancelFunc
+}
+
+func (s *RPCServer) Commands(_ struct{}, resp *[]Command) (err error) {
+	*resp, err = s.impl.Commands()
+	return
+}
+
+func (s *RPCServer) Complete(req CompleteRequest, resp *[]string) (err error) {
+	*resp, err = s.impl.Complete(req.Name, req.Args)
+	return
+}
+
+func (s *RPCServer) Run(req RunRequest, _ *struct{}) error {
+	conn, err := s.broker.Dial(req.ID)
+	if err != nil {
+		return err
+	}
+	out := rpc.NewClient(conn)
+	defer out.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	s.cancels[req.ID] = cancel
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.cancels, req.ID)
+		s.mu.Unlock()
+		cancel()
+	}()
+	return s.impl.Run(ctx, req.Name, req.Args, &outputClient{client: out})
+}
+
+func (s *RPCServer) Cancel(id uint32, _ *struct{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cancel, ok := s.cancels[id]; ok {
+		cancel()
+	}
+	return nil
+}
+
+// OutputServer runs in the host process and receives the output of a command.
+// It's exported for net/rpc only.
+type OutputServer struct {
+	w io.Writer
+}
+
+func (s *OutputServer) Write(p []byte, n *int) (err error) {
+	*n, err = s.w.Write(p)
+	return
+}
+
+type outputClient struct {
+	client *rpc.Client
+}
+
+func (o *outputClient) Write(p []byte) (int, error) {
+	var n int
+	err := o.client.Call("Plugin.Write", p, &n)
+	return n, err
+}
+
+// rpcClient runs in the host process and implements Provider by calling the plugin.
+type rpcClient struct {
+	client *rpc.Client
+	broker *plugin.MuxBroker
+}
+
+func (c *rpcClient) Commands() ([]Command, error) {
+	var resp []Command
+	err := c.client.Call("Plugin.Commands", struct{}{}, &resp)
+	return resp, err
+}
+
+func (c *rpcClient) Complete(name string, args []string) ([]string, error) {
+	var resp []string
+	err := c.client.Call("Plugin.Complete", CompleteRequest{Name: name, Args: args}, &resp)
+	return resp, err
+}
+
+func (c *rpcClient) Run(ctx context.Context, name string, args []string, out io.Writer) error {
+	id := c.broker.NextId()
+	go c.broker.AcceptAndServe(id, &OutputServer{w: out})
+
+	call := c.client.Go("Plugin.Run", RunRequest{ID: id, Name: name, Args: args}, &struct{}{}, nil)
+	select {
+	case <-call.Done:
+	case <-ctx.Done():
+		_ = c.client.Call("Plugin.Cancel", id, &struct{}{})
+		if <-call.Done; call.Error != nil {
+			return ctx.Err()
+		}
+	}
+	return call.Error
+}
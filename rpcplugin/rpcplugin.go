@@ -0,0 +1,191 @@
+// Package rpcplugin loads console commands from plugin executables.
+//
+// Plugins are separate processes speaking net/rpc through hashicorp/go-plugin.
+// A plugin implements Provider and calls Serve from its main function.
+// The host loads it with Load or Discover and registers the resulting commands.
+// Command output is streamed back to the console while the command runs.
+//
+// The package is a module of its own, so go-plugin and its dependencies
+// aren't required by consoles without plugins.
+package rpcplugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	"github.com/jon4hz/console"
+)
+
+// Handshake is shared by the host and its plugins. Executables without
+// the matching cookie refuse to start as a plugin.
+var Handshake = plugin.HandshakeConfig{
+	ProtocolVersion:  1,
+	MagicCookieKey:   "CONSOLE_PLUGIN",
+	MagicCookieValue: "d1b2f6e0-console-commands",
+}
+
+const pluginName = "commands"
+
+// Command describes a command contributed by a plugin.
+type Command struct {
+	Name        string
+	Aliases     []string
+	Description string
+}
+
+// Provider is implemented by plugins.
+type Provider interface {
+	// Commands returns the commands of the plugin.
+	Commands() ([]Command, error)
+	// Complete returns the completion candidates for the last of args.
+	Complete(name string, args []string) ([]string, error)
+	// Run executes a command and writes its output to out. The context is
+	// canceled if the command is canceled on the console.
+	Run(ctx context.Context, name string, args []string, out io.Writer) error
+}
+
+// Serve serves p as plugin. It is called from the main function of the
+// plugin executable and blocks until the host disconnects.
+func Serve(p Provider) {
+	plugin.Serve(&plugin.ServeConfig{
+		HandshakeConfig: Handshake,
+		Plugins:         plugin.PluginSet{pluginName: &rpcPlugin{impl: p}},
+	})
+}
+
+// Plugin is a loaded plugin.
+type Plugin struct {
+	Path     string
+	client   *plugin.Client
+	provider Provider
+	cmds     []*console.Cmd
+}
+
+// Load starts the plugin executable at path and fetches its commands.
+func Load(path string) (*Plugin, error) {
+	client := plugin.NewClient(&plugin.ClientConfig{
+		HandshakeConfig:  Handshake,
+		Plugins:          plugin.PluginSet{pluginName: &rpcPlugin{}},
+		Cmd:              exec.Command(path),
+		AllowedProtocols: []plugin.Protocol{plugin.ProtocolNetRPC},
+		Logger: hclog.New(&hclog.LoggerOptions{
+			Name:   "plugin",
+			Output: os.Stderr,
+			Level:  hclog.Warn,
+		}),
+	})
+	rpcClient, err := client.Client()
+	if err != nil {
+		client.Kill()
+		return nil, fmt.Errorf("failed to start plugin %s: %w", path, err)
+	}
+	raw, err := rpcClient.Dispense(pluginName)
+	if err != nil {
+		client.Kill()
+		return nil, fmt.Errorf("failed to dispense plugin %s: %w", path, err)
+	}
+	p, err := newPlugin(raw.(Provider))
+	if err != nil {
+		client.Kill()
+		return nil, fmt.Errorf("failed to load commands of plugin %s: %w", path, err)
+	}
+	p.Path = path
+	p.client = client
+	return p, nil
+}
+
+func newPlugin(provider Provider) (*Plugin, error) {
+	infos, err := provider.Commands()
+	if err != nil {
+		return nil, err
+	}
+	p := &Plugin{provider: provider}
+	for _, info := range infos {
+		name := info.Name
+		p.cmds = append(p.cmds, &console.Cmd{
+			Name:        name,
+			Aliases:     info.Aliases,
+			Description: info.Description,
+			// the arguments are passed to the plugin as they are
+			ParsesOutputFlag: true,
+			Completer: func(_ *console.Console, args []string) []string {
+				s, err := provider.Complete(name, args)
+				if err != nil {
+					return nil
+				}
+				return s
+			},
+			ContextHandler: func(ctx context.Context, c *console.Console, args []string) error {
+				return provider.Run(ctx, name, args, c.Writer(ctx))
+			},
+		})
+	}
+	return p, nil
+}
+
+// Cmds returns the commands of the plugin.
+func (p *Plugin) Cmds() []*console.Cmd {
+	return p.cmds
+}
+
+// Close stops the plugin process.
+func (p *Plugin) Close() {
+	if p.client != nil {
+		p.client.Kill()
+	}
+}
+
+// Discover loads every executable in dir as plugin.
+// If a plugin fails to load, the already loaded ones are closed.
+func Discover(dir string) ([]*Plugin, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var plugins []*Plugin
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			closeAll(plugins)
+			return nil, err
+		}
+		if !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+			continue
+		}
+		p, err := Load(filepath.Join(dir, e.Name()))
+		if err != nil {
+			closeAll(plugins)
+			return nil, err
+		}
+		plugins = append(plugins, p)
+	}
+	return plugins, nil
+}
+
+// Register loads the plugins in dir and registers their commands with c.
+// The returned plugins must be closed once the console is closed.
+func Register(c *console.Console, dir string) ([]*Plugin, error) {
+	plugins, err := Discover(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range plugins {
+		if err := c.RegisterCommands(p.Cmds()...); err != nil {
+			closeAll(plugins)
+			return nil, fmt.Errorf("plugin %s: %w", p.Path, err)
+		}
+	}
+	return plugins, nil
+}
+
+func closeAll(plugins []*Plugin) {
+	for _, p := range plugins {
+		p.Close()
+	}
+}
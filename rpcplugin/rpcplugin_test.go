@@ -0,0 +1,74 @@
+package rpcplugin
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-plugin"
+	"github.com/jon4hz/console"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeProvider struct{}
+
+func (fakeProvider) Commands() ([]Command, error) {
+	return []Command{
+		{Name: "greet", Description: "Greet someone"},
+		{Name: "wait"},
+	}, nil
+}
+
+func (fakeProvider) Complete(_ string, args []string) ([]string, error) {
+	var s []string
+	for _, n := range []string{"alice", "bob"} {
+		if strings.HasPrefix(n, args[len(args)-1]) {
+			s = append(s, n)
+		}
+	}
+	return s, nil
+}
+
+func (fakeProvider) Run(ctx context.Context, name string, args []string, out io.Writer) error {
+	switch name {
+	case "greet":
+		for _, a := range args {
+			fmt.Fprintf(out, "hello %s\n", a)
+		}
+		return nil
+	case "wait":
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return fmt.Errorf("unknown command %s", name)
+}
+
+func TestPlugin(t *testing.T) {
+	client, _ := plugin.TestPluginRPCConn(t, plugin.PluginSet{pluginName: &rpcPlugin{impl: fakeProvider{}}}, nil)
+	defer client.Close()
+	raw, err := client.Dispense(pluginName)
+	assert.NoError(t, err)
+
+	p, err := newPlugin(raw.(Provider))
+	assert.NoError(t, err)
+	cmds := p.Cmds()
+	assert.Len(t, cmds, 2)
+	assert.Equal(t, "Greet someone", cmds[0].Description)
+
+	var out bytes.Buffer
+	c, err := console.New(console.WithOutput(&out))
+	assert.NoError(t, err)
+	defer c.Close()
+
+	assert.Equal(t, []string{"bob"}, cmds[0].Completer(c, []string{"b"}))
+	assert.NoError(t, cmds[0].ContextHandler(context.Background(), c, []string{"alice", "bob"}))
+	assert.Equal(t, "hello alice\nhello bob\n", out.String())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, cmds[1].ContextHandler(ctx, c, nil), context.DeadlineExceeded)
+}
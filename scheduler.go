@@ -0,0 +1,305 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jon4hz/console/parse"
+)
+
+var (
+	ErrUnknownJob = errors.New("unknown job")
+	// ErrInteractiveJob is returned for jobs running commands which need input,
+	// like dangerous commands to be confirmed or sudo.
+	ErrInteractiveJob = errors.New("command needs interactive input")
+)
+
+// Job is a command line scheduled with Every or At.
+type Job struct {
+	ID int
+	// Spec describes the schedule, like "every 30s" or "at 2026-01-02 14:00:00".
+	Spec string
+	Line string
+	// Next is the time of the next run.
+	Next time.Time
+}
+
+type job struct {
+	Job
+	cancel context.CancelFunc
+	// elevated reports whether the privileges were elevated when the job was scheduled.
+	elevated bool
+}
+
+// scheduler holds the jobs of a session.
+type scheduler struct {
+	mu     sync.Mutex
+	jobs   map[int]*job
+	lastID int
+}
+
+// WithScheduler registers the every, at and schedule builtins, which run
+// commands periodically or once at a given time.
+func WithScheduler() Opts {
+	return func(c *Console) {
+		c.schedulerCmds = true
+	}
+}
+
+// Every runs line every interval until the job is canceled or the console
+// is closed. Runs are skipped while the previous one is still running.
+// The output of the command is tagged with the job. Commands which need
+// input, like dangerous commands to be confirmed, fail with ErrInteractiveJob.
+// Jobs scheduled without elevated privileges run without them, even if
+// the privileges of the session were elevated since.
+func (c *Console) Every(interval time.Duration, line string) (int, error) {
+	if interval <= 0 {
+		return 0, fmt.Errorf("invalid interval %s", interval)
+	}
+	return c.addJob("every "+interval.String(), line, time.Now().Add(interval), func(ctx context.Context, j *job) {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-t.C:
+				c.setNext(j, now.Add(interval))
+				c.runJob(ctx, j)
+			}
+		}
+	})
+}
+
+// At runs line once at t.
+func (c *Console) At(t time.Time, line string) (int, error) {
+	return c.addJob("at "+t.Format("2006-01-02 15:04:05"), line, t, func(ctx context.Context, j *job) {
+		timer := time.NewTimer(time.Until(t))
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		c.runJob(ctx, j)
+		c.CancelJob(j.ID)
+	})
+}
+
+// Jobs returns the scheduled jobs ordered by ID.
+func (c *Console) Jobs() []Job {
+	c.jobs.mu.Lock()
+	defer c.jobs.mu.Unlock()
+	jobs := make([]Job, 0, len(c.jobs.jobs))
+	for _, j := range c.jobs.jobs {
+		jobs = append(jobs, j.Job)
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].ID < jobs[k].ID })
+	return jobs
+}
+
+// CancelJob cancels a scheduled job. A running command isn't interrupted.
+func (c *Console) CancelJob(id int) error {
+	c.jobs.mu.Lock()
+	defer c.jobs.mu.Unlock()
+	j, ok := c.jobs.jobs[id]
+	if !ok {
+		return fmt.Errorf("%w #%d", ErrUnknownJob, id)
+	}
+	j.cancel()
+	delete(c.jobs.jobs, id)
+	return nil
+}
+
+func (c *Console) addJob(spec, line string, next time.Time, run func(ctx context.Context, j *job)) (int, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return 0, errors.New("no command to schedule")
+	}
+	if err := c.checkJob(line); err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.jobs.mu.Lock()
+	if c.jobs.jobs == nil {
+		c.jobs.jobs = make(map[int]*job)
+	}
+	c.jobs.lastID++
+	j := &job{Job: Job{ID: c.jobs.lastID, Spec: spec, Line: line, Next: next}, cancel: cancel, elevated: c.Elevated()}
+	c.jobs.jobs[j.ID] = j
+	c.jobs.mu.Unlock()
+	go run(ctx, j)
+	return j.ID, nil
+}
+
+func (c *Console) setNext(j *job, next time.Time) {
+	c.jobs.mu.Lock()
+	defer c.jobs.mu.Unlock()
+	j.Next = next
+}
+
+// checkJob returns an error if the command of line can't run unattended.
+// Nobody is there to confirm a dangerous command or to enter a password.
+// It's checked again before every run as aliases or the dry-run mode may change.
+func (c *Console) checkJob(line string) error {
+	line = c.expandAlias(line)
+	inv, err := parse.Parse(line)
+	if err != nil {
+		return err
+	}
+	cmd, _ := c.lookup(line, inv)
+	if cmd == nil {
+		return fmt.Errorf("%w: %s", ErrCmdNotFound, line)
+	}
+	if cmd.interactive || (c.confirmPolicy(cmd.Danger) != ConfirmNone && !c.DryRun()) {
+		return fmt.Errorf("%w: %s", ErrInteractiveJob, cmd.Name)
+	}
+	return nil
+}
+
+// runJob runs the line of j once no other command is running.
+// Its output is printed above the prompt.
+func (c *Console) runJob(ctx context.Context, j *job) {
+	tag := fmt.Sprintf("[job#%d] ", j.ID)
+	out := &promptWriter{c: c}
+	tw := &tagWriter{w: out, tag: tag}
+	ctx = context.WithValue(ctx, execKey{}, &execution{id: atomic.AddUint64(&c.execID, 1), out: tw})
+	run := func() error {
+		if err := c.checkJob(j.Line); err != nil {
+			return err
+		}
+		return c.Run(ctx, j.Line)
+	}
+	c.execMu.Lock()
+	var err error
+	if j.elevated {
+		err = run()
+	} else {
+		err = c.unelevated(run)
+	}
+	c.execMu.Unlock()
+	tw.Flush()
+	if err != nil {
+		fmt.Fprintln(out, c.theme.Error.Render(c.msg(MsgCmdFailed, tag, err)))
+	}
+}
+
+// parseAt parses the time of the at builtin, either a time of the day
+// like 14:00 or 14:00:30, which is tomorrow if it has passed, or RFC 3339.
+func parseAt(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		t, err := time.ParseInLocation(layout, s, now.Location())
+		if err != nil {
+			continue
+		}
+		t = time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, now.Location())
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use 15:04, 15:04:05 or RFC 3339", s)
+}
+
+var everyCmd = &Cmd{
+	Name:        "every",
+	Description: "Run a command periodically",
+	descID:      MsgEveryDescription,
+	builtin:     true,
+	Args:        []*Arg{{Name: "interval"}, {Name: "command"}},
+	ContextHandler: func(ctx context.Context, c *Console, args []string) error {
+		if len(args) < 2 {
+			return errors.New(c.msg(MsgUsageError, "every <interval> <command> [args...]"))
+		}
+		d, err := time.ParseDuration(args[0])
+		if err != nil {
+			return err
+		}
+		id, err := c.Every(d, parse.Join(args[1:]))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.Writer(ctx), c.msg(MsgJobScheduled, id))
+		return nil
+	},
+}
+
+var atCmd = &Cmd{
+	Name:        "at",
+	Description: "Run a command at a given time",
+	descID:      MsgAtDescription,
+	builtin:     true,
+	Args:        []*Arg{{Name: "time"}, {Name: "command"}},
+	ContextHandler: func(ctx context.Context, c *Console, args []string) error {
+		if len(args) < 2 {
+			return errors.New(c.msg(MsgUsageError, "at <time> <command> [args...]"))
+		}
+		t, err := parseAt(args[0], time.Now())
+		if err != nil {
+			return err
+		}
+		id, err := c.At(t, parse.Join(args[1:]))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.Writer(ctx), c.msg(MsgJobScheduled, id))
+		return nil
+	},
+}
+
+var scheduleCmd = &Cmd{
+	Name:        "schedule",
+	Description: "List or cancel scheduled commands",
+	descID:      MsgScheduleDescription,
+	builtin:     true,
+	Args:        []*Arg{{Name: "action", Values: []string{"cancel", "list"}}, {Name: "id"}},
+	Completer: func(c *Console, args []string) []string {
+		if len(args) == 2 && args[0] == "cancel" {
+			var ids []string
+			for _, j := range c.Jobs() {
+				if id := strconv.Itoa(j.ID); strings.HasPrefix(id, args[1]) {
+					ids = append(ids, id)
+				}
+			}
+			return ids
+		}
+		var s []string
+		for _, v := range []string{"cancel", "list"} {
+			if len(args) == 1 && strings.HasPrefix(v, args[0]) {
+				s = append(s, v)
+			}
+		}
+		return s
+	},
+	ContextHandler: func(ctx context.Context, c *Console, args []string) error {
+		if len(args) == 0 || args[0] == "list" {
+			w := c.Writer(ctx)
+			jobs := c.Jobs()
+			if len(jobs) == 0 {
+				fmt.Fprintln(w, c.msg(MsgNoJobs))
+			}
+			for _, j := range jobs {
+				fmt.Fprintf(w, "#%d  %s  %s  (next %s)\n", j.ID, j.Spec, j.Line, j.Next.Format("15:04:05"))
+			}
+			return nil
+		}
+		if args[0] != "cancel" || len(args) != 2 {
+			return errors.New(c.msg(MsgUsageError, "schedule [list | cancel <id>]"))
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(args[1], "#"))
+		if err != nil {
+			return errors.New(c.msg(MsgInvalidJob, args[1]))
+		}
+		return c.CancelJob(id)
+	},
+}
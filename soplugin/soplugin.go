@@ -0,0 +1,175 @@
+// Package soplugin loads command packs from Go plugins.
+//
+// A command pack is built with go build -buildmode=plugin and exports
+//
+//	func Commands() []*console.Cmd
+//
+// Go plugins are only supported on some platforms and must be built with
+// the same toolchain and dependency versions as the host. They can't be
+// removed from the process, so unloading a pack only unregisters its
+// commands and loading it again reuses the already opened plugin.
+package soplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"plugin"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jon4hz/console"
+)
+
+// Symbol is the name of the function exported by command packs.
+const Symbol = "Commands"
+
+var (
+	ErrLoaded    = errors.New("command pack already loaded")
+	ErrNotLoaded = errors.New("command pack not loaded")
+)
+
+// Open opens the plugin at path and returns its commands.
+func Open(path string) ([]*console.Cmd, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	sym, err := p.Lookup(Symbol)
+	if err != nil {
+		return nil, err
+	}
+	fn, ok := sym.(func() []*console.Cmd)
+	if !ok {
+		return nil, fmt.Errorf("%s of %s is %T, not func() []*console.Cmd", Symbol, path, sym)
+	}
+	return fn(), nil
+}
+
+// Loader loads and unloads command packs of a console.
+type Loader struct {
+	// Dir is used to resolve relative paths and to complete pack names.
+	Dir string
+
+	open  func(path string) ([]*console.Cmd, error)
+	mu    sync.Mutex
+	packs map[string][]*console.Cmd
+}
+
+// NewLoader creates a loader resolving relative paths in dir.
+func NewLoader(dir string) *Loader {
+	return &Loader{Dir: dir, open: Open, packs: make(map[string][]*console.Cmd)}
+}
+
+func (l *Loader) path(name string) string {
+	if filepath.IsAbs(name) || l.Dir == "" {
+		return filepath.Clean(name)
+	}
+	return filepath.Join(l.Dir, name)
+}
+
+// Load loads the command pack at path and registers its commands with c.
+func (l *Loader) Load(c *console.Console, path string) error {
+	path = l.path(path)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.packs[path]; ok {
+		return ErrLoaded
+	}
+	cmds, err := l.open(path)
+	if err != nil {
+		return err
+	}
+	// RegisterCommands registers either all commands of the pack or none
+	if err := c.RegisterCommands(cmds...); err != nil {
+		return err
+	}
+	l.packs[path] = cmds
+	return nil
+}
+
+// Unload unregisters the commands of the command pack at path from c.
+func (l *Loader) Unload(c *console.Console, path string) error {
+	path = l.path(path)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	cmds, ok := l.packs[path]
+	if !ok {
+		return ErrNotLoaded
+	}
+	c.UnregisterCommands(cmds...)
+	delete(l.packs, path)
+	return nil
+}
+
+// Loaded returns the paths of the loaded command packs.
+func (l *Loader) Loaded() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := make([]string, 0, len(l.packs))
+	for p := range l.packs {
+		s = append(s, p)
+	}
+	sort.Strings(s)
+	return s
+}
+
+// Cmds returns the load and unload builtins.
+func (l *Loader) Cmds() []*console.Cmd {
+	return []*console.Cmd{
+		{
+			Name:        "load",
+			Description: "Load a command pack",
+			Completer: func(_ *console.Console, args []string) []string {
+				return l.completeFiles(args[len(args)-1])
+			},
+			ContextHandler: func(ctx context.Context, c *console.Console, args []string) error {
+				if len(args) != 1 {
+					return errors.New("usage: load <path>")
+				}
+				if err := l.Load(c, args[0]); err != nil {
+					return err
+				}
+				fmt.Fprintf(c.Writer(ctx), "loaded %s\n", l.path(args[0]))
+				return nil
+			},
+		},
+		{
+			Name:        "unload",
+			Description: "Unload a command pack",
+			Completer: func(_ *console.Console, args []string) (s []string) {
+				for _, p := range l.Loaded() {
+					if strings.HasPrefix(p, args[len(args)-1]) {
+						s = append(s, p)
+					}
+				}
+				return
+			},
+			ContextHandler: func(ctx context.Context, c *console.Console, args []string) error {
+				if len(args) != 1 {
+					return errors.New("usage: unload <path>")
+				}
+				if err := l.Unload(c, args[0]); err != nil {
+					return err
+				}
+				fmt.Fprintf(c.Writer(ctx), "unloaded %s\n", l.path(args[0]))
+				return nil
+			},
+		},
+	}
+}
+
+func (l *Loader) completeFiles(prefix string) (s []string) {
+	if l.Dir == "" {
+		return nil
+	}
+	matches, _ := filepath.Glob(filepath.Join(l.Dir, "*.so"))
+	for _, m := range matches {
+		if n := filepath.Base(m); strings.HasPrefix(n, prefix) {
+			s = append(s, n)
+		}
+	}
+	return
+}
@@ -0,0 +1,47 @@
+package soplugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jon4hz/console"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoader(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "pack.so"), nil, 0o600))
+
+	l := NewLoader(dir)
+	l.open = func(path string) ([]*console.Cmd, error) {
+		return []*console.Cmd{{Name: "hello", Handler: func(*console.Console, []string) error { return nil }}}, nil
+	}
+	c, err := console.New()
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.RegisterCommands(l.Cmds()...))
+
+	load, unload := l.Cmds()[0], l.Cmds()[1]
+	assert.Equal(t, []string{"pack.so"}, load.Completer(c, []string{"pa"}))
+
+	assert.NoError(t, l.Load(c, "pack.so"))
+	assert.ErrorIs(t, l.Load(c, "pack.so"), ErrLoaded)
+	assert.Equal(t, []string{filepath.Join(dir, "pack.so")}, l.Loaded())
+	assert.Len(t, unload.Completer(c, []string{dir}), 1)
+	assert.Error(t, c.RegisterCommands(&console.Cmd{Name: "hello"}))
+
+	assert.NoError(t, l.Unload(c, "pack.so"))
+	assert.ErrorIs(t, l.Unload(c, "pack.so"), ErrNotLoaded)
+	assert.NoError(t, c.RegisterCommands(&console.Cmd{Name: "hello"}))
+
+	// a colliding pack leaves the registered commands alone
+	assert.Error(t, l.Load(c, "pack.so"))
+	assert.Empty(t, l.Loaded())
+	assert.Error(t, c.RegisterCommands(&console.Cmd{Name: "hello"}))
+}
+
+func TestOpenInvalid(t *testing.T) {
+	_, err := Open(filepath.Join(t.TempDir(), "missing.so"))
+	assert.Error(t, err)
+}
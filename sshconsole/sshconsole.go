@@ -0,0 +1,64 @@
+// Package sshconsole serves a console per incoming SSH connection.
+// It's a module of its own, so the SSH server isn't a dependency of every console.
+package sshconsole
+
+import (
+	"fmt"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/jon4hz/console"
+)
+
+// NewSession creates the console of an SSH session.
+// The given options connect the console to the session and must be passed to console.New
+// or, to share the commands between sessions, to console.Engine.NewSession.
+type NewSession func(s ssh.Session, opts ...console.Opts) (*console.Console, error)
+
+// Handler returns an ssh.Handler running a console for each session.
+// Every session gets its own console, so no state is shared between connections.
+// Sessions without a PTY are rejected.
+func Handler(newSession NewSession) ssh.Handler {
+	return func(s ssh.Session) {
+		pty, winC, ok := s.Pty()
+		if !ok {
+			fmt.Fprintln(s.Stderr(), "a PTY is required")
+			s.Exit(1)
+			return
+		}
+
+		tr := console.NewTerminalReader(s)
+		tr.SetSize(pty.Window.Width, pty.Window.Height)
+		go func() {
+			for win := range winC {
+				tr.SetSize(win.Width, win.Height)
+			}
+		}()
+
+		c, err := newSession(s,
+			console.WithLineReader(tr),
+			console.WithOutput(tr),
+			console.WithContext(s.Context()),
+			console.WithHistoryFile(""),
+			console.WithIdentity(console.Identity{Name: s.User()}),
+		)
+		if err != nil {
+			fmt.Fprintln(s.Stderr(), err)
+			s.Exit(1)
+			return
+		}
+		defer c.Close()
+
+		if err := c.Start(); err != nil {
+			fmt.Fprintln(tr, err)
+			s.Exit(1)
+			return
+		}
+		s.Exit(0)
+	}
+}
+
+// ListenAndServe serves consoles on addr.
+// The options configure the SSH server, e.g. the host key and authentication.
+func ListenAndServe(addr string, newSession NewSession, options ...ssh.Option) error {
+	return ssh.ListenAndServe(addr, Handler(newSession), options...)
+}
@@ -0,0 +1,61 @@
+package sshconsole
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/jon4hz/console"
+	"github.com/stretchr/testify/assert"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// serve serves consoles on a local port and returns a connected client.
+func serve(t *testing.T) *gossh.Client {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	srv := &ssh.Server{Handler: Handler(func(s ssh.Session, opts ...console.Opts) (*console.Console, error) {
+		return console.New(opts...)
+	})}
+	go srv.Serve(l)
+	t.Cleanup(func() { srv.Close() })
+
+	client, err := gossh.Dial("tcp", l.Addr().String(), &gossh.ClientConfig{
+		User:            "alice",
+		HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestHandler(t *testing.T) {
+	s, err := serve(t).NewSession()
+	assert.NoError(t, err)
+	defer s.Close()
+
+	var out bytes.Buffer
+	s.Stdin = strings.NewReader("help\rquit\r")
+	s.Stdout = &out
+	assert.NoError(t, s.RequestPty("xterm", 24, 80, gossh.TerminalModes{}))
+	assert.NoError(t, s.Shell())
+	assert.NoError(t, s.Wait())
+	assert.Contains(t, out.String(), "Available commands:")
+}
+
+func TestHandlerWithoutPty(t *testing.T) {
+	s, err := serve(t).NewSession()
+	assert.NoError(t, err)
+	defer s.Close()
+
+	var stderr bytes.Buffer
+	s.Stderr = &stderr
+	var exitErr *gossh.ExitError
+	assert.ErrorAs(t, s.Run(""), &exitErr)
+	assert.Equal(t, 1, exitErr.ExitStatus())
+	assert.Equal(t, "a PTY is required\n", stderr.String())
+}
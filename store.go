@@ -0,0 +1,49 @@
+package console
+
+// Set stores a value in the session, e.g. the currently selected resource.
+// Values are not shared with other sessions of the same engine.
+func (c *Console) Set(key string, value any) {
+	c.storeMu.Lock()
+	defer c.storeMu.Unlock()
+	if c.store == nil {
+		c.store = make(map[string]any)
+	}
+	c.store[key] = value
+}
+
+// Get returns a value stored in the session.
+func (c *Console) Get(key string) (any, bool) {
+	c.storeMu.RLock()
+	defer c.storeMu.RUnlock()
+	v, ok := c.store[key]
+	return v, ok
+}
+
+// Delete removes a value from the session.
+func (c *Console) Delete(key string) {
+	c.storeMu.Lock()
+	defer c.storeMu.Unlock()
+	delete(c.store, key)
+}
+
+// Keys returns the keys of all values stored in the session.
+func (c *Console) Keys() []string {
+	c.storeMu.RLock()
+	defer c.storeMu.RUnlock()
+	keys := make([]string, 0, len(c.store))
+	for k := range c.store {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// GetAs returns a value stored in the session if it has the type T.
+func GetAs[T any](c *Console, key string) (T, bool) {
+	v, ok := c.Get(key)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	t, ok := v.(T)
+	return t, ok
+}
@@ -0,0 +1,74 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Runner is implemented by commands declared as structs.
+// Run is called on a fresh copy of the struct with the flags and
+// arguments of the invocation bound to its fields.
+type Runner interface {
+	Run(ctx context.Context, c *Console) error
+}
+
+// RegisterStruct registers commands declared as structs.
+// See StructCmd for the declaration. Like RegisterCommands,
+// nothing is registered on error.
+func (c *Console) RegisterStruct(vs ...Runner) error {
+	cmds := make([]*Cmd, 0, len(vs))
+	for _, v := range vs {
+		cmd, err := StructCmd(v)
+		if err != nil {
+			return err
+		}
+		cmds = append(cmds, cmd)
+	}
+	return c.RegisterCommands(cmds...)
+}
+
+// StructCmd creates a command from a struct implementing Runner.
+// The command is described by the tags of a blank field and its
+// flags and arguments by the tags of the other fields:
+//
+//	type Deploy struct {
+//		_       struct{} `cmd:"deploy" aliases:"d" help:"Deploy a service"`
+//		Env     string   `flag:"env" short:"e" default:"staging" enum:"staging,prod" help:"Target environment"`
+//		Force   bool     `flag:"force" help:"Skip the checks"`
+//		Service string   `arg:"service" required:"true" help:"Service to deploy"`
+//	}
+//
+// If the cmd tag is missing, the name is derived from the type name.
+// The usage is printed with --help. Fields without tags are copied from v
+// on every invocation and may carry dependencies of the command.
+func StructCmd(v Runner) (*Cmd, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return nil, errors.New("struct command must be a non-nil pointer to a struct")
+	}
+	t := rv.Elem().Type()
+	b, err := newBinding(t, false)
+	if err != nil {
+		return nil, err
+	}
+	cmd := &Cmd{Name: kebabCase(t.Name())}
+	if sf, ok := t.FieldByName("_"); ok {
+		if n := sf.Tag.Get("cmd"); n != "" {
+			cmd.Name = n
+		}
+		cmd.Description = sf.Tag.Get("help")
+		if a := sf.Tag.Get("aliases"); a != "" {
+			cmd.Aliases = strings.Split(a, ",")
+		}
+	}
+	if cmd.Name == "" {
+		return nil, fmt.Errorf("struct command %s has no name", t)
+	}
+	b.wire(cmd, func(ctx context.Context, c *Console, v reflect.Value) error {
+		return v.Interface().(Runner).Run(ctx, c)
+	}, rv.Elem())
+	return cmd, nil
+}
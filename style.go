@@ -3,3 +3,26 @@ package console
 import "github.com/charmbracelet/lipgloss"
 
 var StyleError = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#FF4672", Dark: "#FF4672"})
+
+var StyleStack = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#A49FA5", Dark: "#777777"})
+
+// Theme holds the styles of a console.
+type Theme struct {
+	Error lipgloss.Style
+	Stack lipgloss.Style
+}
+
+// DefaultTheme returns the theme using StyleError and StyleStack.
+func DefaultTheme() Theme {
+	return Theme{
+		Error: StyleError,
+		Stack: StyleStack,
+	}
+}
+
+// WithTheme sets the styles of the console.
+func WithTheme(t Theme) Opts {
+	return func(c *Console) {
+		c.theme = t
+	}
+}
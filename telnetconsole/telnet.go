@@ -0,0 +1,210 @@
+package telnetconsole
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// telnet commands and options, see RFC 854, 857, 858 and 1073
+const (
+	cmdSE   = 240
+	cmdSB   = 250
+	cmdWILL = 251
+	cmdWONT = 252
+	cmdDO   = 253
+	cmdDONT = 254
+	cmdIAC  = 255
+
+	optEcho = 1
+	optSGA  = 3
+	optNAWS = 31
+)
+
+type parseState int
+
+const (
+	stateData parseState = iota
+	stateIAC
+	stateVerb
+	stateSB
+	stateSBIAC
+)
+
+// conn strips telnet commands from the input and escapes the output.
+type conn struct {
+	net.Conn
+
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	raw      []byte
+	state    parseState
+	verb     byte
+	sb       []byte
+	lastCR   bool
+	echo     bool
+	sga      bool
+	answered bool
+	// charMode is set once the client agreed to character mode.
+	charMode bool
+
+	width, height int
+	onResize      func(width, height int)
+}
+
+func newConn(c net.Conn) *conn {
+	return &conn{Conn: c, raw: make([]byte, 1024)}
+}
+
+// negotiate asks the client for character mode and reports whether it agreed.
+func (c *conn) negotiate(timeout time.Duration) bool {
+	if _, err := c.Conn.Write([]byte{
+		cmdIAC, cmdWILL, optEcho,
+		cmdIAC, cmdWILL, optSGA,
+		cmdIAC, cmdDO, optNAWS,
+	}); err != nil {
+		return false
+	}
+	c.Conn.SetReadDeadline(time.Now().Add(timeout))
+	defer c.Conn.SetReadDeadline(time.Time{})
+	for !c.answered {
+		if err := c.fill(); err != nil {
+			break
+		}
+	}
+	c.charMode = c.echo && c.sga
+	return c.charMode
+}
+
+func (c *conn) Read(p []byte) (int, error) {
+	for c.buf.Len() == 0 {
+		if err := c.fill(); err != nil {
+			return 0, err
+		}
+	}
+	return c.buf.Read(p)
+}
+
+// fill reads from the connection and parses the received bytes.
+func (c *conn) fill() error {
+	n, err := c.Conn.Read(c.raw)
+	for _, b := range c.raw[:n] {
+		c.parse(b)
+	}
+	if n > 0 {
+		return nil
+	}
+	return err
+}
+
+func (c *conn) parse(b byte) {
+	switch c.state {
+	case stateData:
+		if b == cmdIAC {
+			c.state = stateIAC
+			return
+		}
+		// a carriage return is followed by NUL or LF. In character mode the
+		// TerminalReader ends the line on CR, otherwise the PlainReader needs a LF.
+		if c.lastCR && (b == 0 || b == '\n') {
+			c.lastCR = false
+			if !c.charMode {
+				c.buf.WriteByte('\n')
+			}
+			return
+		}
+		c.lastCR = b == '\r'
+		c.buf.WriteByte(b)
+	case stateIAC:
+		switch b {
+		case cmdIAC:
+			c.buf.WriteByte(b)
+			c.state = stateData
+		case cmdWILL, cmdWONT, cmdDO, cmdDONT:
+			c.verb = b
+			c.state = stateVerb
+		case cmdSB:
+			c.sb = c.sb[:0]
+			c.state = stateSB
+		default:
+			c.state = stateData
+		}
+	case stateVerb:
+		c.option(c.verb, b)
+		c.state = stateData
+	case stateSB:
+		if b == cmdIAC {
+			c.state = stateSBIAC
+			return
+		}
+		c.sb = append(c.sb, b)
+	case stateSBIAC:
+		switch b {
+		case cmdSE:
+			c.subnegotiation(c.sb)
+			c.state = stateData
+		case cmdIAC:
+			c.sb = append(c.sb, b)
+			c.state = stateSB
+		default:
+			c.state = stateData
+		}
+	}
+}
+
+func (c *conn) option(verb, opt byte) {
+	switch opt {
+	case optEcho:
+		c.echo = verb == cmdDO
+		c.answered = true
+	case optSGA:
+		c.sga = verb == cmdDO
+	case optNAWS:
+		// answer to our request, the size follows as subnegotiation
+	default:
+		// refuse everything we didn't ask for
+		switch verb {
+		case cmdDO:
+			c.Conn.Write([]byte{cmdIAC, cmdWONT, opt})
+		case cmdWILL:
+			c.Conn.Write([]byte{cmdIAC, cmdDONT, opt})
+		}
+	}
+}
+
+func (c *conn) subnegotiation(sb []byte) {
+	if len(sb) != 5 || sb[0] != optNAWS {
+		return
+	}
+	c.width = int(sb[1])<<8 | int(sb[2])
+	c.height = int(sb[3])<<8 | int(sb[4])
+	if c.onResize != nil {
+		c.onResize(c.width, c.height)
+	}
+}
+
+func (c *conn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if bytes.IndexByte(p, cmdIAC) < 0 {
+		return c.Conn.Write(p)
+	}
+	if _, err := c.Conn.Write(bytes.ReplaceAll(p, []byte{cmdIAC}, []byte{cmdIAC, cmdIAC})); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// crlfWriter translates newlines to the CRLF sequence required by the network virtual terminal.
+type crlfWriter struct {
+	w io.Writer
+}
+
+func (w crlfWriter) Write(p []byte) (int, error) {
+	if _, err := w.w.Write(bytes.ReplaceAll(bytes.ReplaceAll(p, []byte("\r\n"), []byte("\n")), []byte("\n"), []byte("\r\n"))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
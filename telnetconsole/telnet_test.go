@@ -0,0 +1,72 @@
+package telnetconsole
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/jon4hz/console"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	c := newConn(server)
+	c.charMode = true
+
+	go client.Write([]byte{
+		cmdIAC, cmdDO, optEcho,
+		cmdIAC, cmdSB, optNAWS, 0, 100, 0, 40, cmdIAC, cmdSE,
+		'h', 'i', '\r', 0,
+		cmdIAC, cmdIAC,
+	})
+
+	buf := make([]byte, 4)
+	_, err := io.ReadFull(c, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{'h', 'i', '\r', cmdIAC}, buf)
+	assert.True(t, c.echo)
+	assert.Equal(t, 100, c.width)
+	assert.Equal(t, 40, c.height)
+}
+
+func TestParseLineMode(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	r := console.NewPlainReader(newConn(server), io.Discard)
+
+	go client.Write([]byte("help\r\nquit\r\x00"))
+
+	for _, want := range []string{"help", "quit"} {
+		line, err := r.Prompt("> ")
+		assert.NoError(t, err)
+		assert.Equal(t, want, line)
+	}
+}
+
+func TestServeRaw(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	s := &Server{
+		Raw: true,
+		NewSession: func(conn net.Conn, opts ...console.Opts) (*console.Console, error) {
+			return console.New(opts...)
+		},
+	}
+	go s.Serve(l)
+	defer l.Close()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	// CRLF like telnet in line mode or netcat on Windows
+	_, err = conn.Write([]byte("help\r\nquit\r\n"))
+	assert.NoError(t, err)
+
+	out, _ := io.ReadAll(bufio.NewReader(conn))
+	assert.True(t, strings.Contains(string(out), "Available commands:\r\n"), string(out))
+}
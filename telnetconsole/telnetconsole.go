@@ -0,0 +1,94 @@
+// Package telnetconsole serves a console per incoming telnet or raw TCP connection.
+//
+// Telnet clients are switched to character mode, so the console supports line editing
+// and completion. Clients refusing character mode, like raw TCP clients, fall back to
+// line mode without editing.
+package telnetconsole
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/jon4hz/console"
+)
+
+// NewSession creates the console of a connection.
+// The given options connect the console to the connection and must be passed
+// to console.New or console.Engine.NewSession.
+type NewSession func(conn net.Conn, opts ...console.Opts) (*console.Console, error)
+
+// Server accepts connections and runs a console for each of them.
+type Server struct {
+	NewSession NewSession
+	// Raw disables the telnet option negotiation for plain TCP clients like netcat.
+	Raw bool
+	// NegotiationTimeout is the time to wait for the answer of the client.
+	// Defaults to one second.
+	NegotiationTimeout time.Duration
+}
+
+// ListenAndServe listens on the TCP address addr and serves consoles.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l until it's closed.
+func (s *Server) Serve(l net.Listener) error {
+	defer l.Close()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(nc net.Conn) {
+	defer nc.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := newConn(nc)
+	opts := []console.Opts{
+		console.WithContext(ctx),
+		console.WithHistoryFile(""),
+		console.WithIdentity(console.Identity{Name: nc.RemoteAddr().String()}),
+	}
+
+	timeout := s.NegotiationTimeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	if !s.Raw && c.negotiate(timeout) {
+		tr := console.NewTerminalReader(c)
+		if c.width > 0 && c.height > 0 {
+			tr.SetSize(c.width, c.height)
+		}
+		c.onResize = func(width, height int) { tr.SetSize(width, height) }
+		opts = append(opts, console.WithLineReader(tr), console.WithOutput(tr))
+	} else {
+		w := crlfWriter{c}
+		opts = append(opts, console.WithLineReader(console.NewPlainReader(c, w)), console.WithOutput(w))
+	}
+
+	con, err := s.NewSession(nc, opts...)
+	if err != nil {
+		fmt.Fprintf(nc, "%s\r\n", err)
+		return
+	}
+	defer con.Close()
+	if err := con.Start(); err != nil {
+		con.Println(err)
+	}
+}
@@ -0,0 +1,195 @@
+package console
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// TerminalReader is a LineReader for terminals connected through an io.ReadWriter,
+// e.g. SSH sessions or network connections. The remote terminal must be in raw mode.
+//
+// TerminalReader also implements io.Writer. Writes while a prompt is displayed
+// redraw the prompt, so it should be used as the output of the console.
+type TerminalReader struct {
+	t       *term.Terminal
+	history *history
+
+	mu          sync.Mutex
+	completer   func(line string) []string
+	valuePicker bool
+	tab         tabState
+	// width and height are set by SetSize.
+	width, height int
+}
+
+// tabState tracks consecutive tab presses to cycle through the completion candidates.
+type tabState struct {
+	candidates []string
+	index      int
+	last       string
+}
+
+// NewTerminalReader creates a TerminalReader reading from and writing to rw.
+func NewTerminalReader(rw io.ReadWriter) *TerminalReader {
+	r := &TerminalReader{
+		t:           term.NewTerminal(rw, ""),
+		history:     &history{},
+		valuePicker: true,
+	}
+	r.t.History = r.history
+	r.t.AutoCompleteCallback = r.autoComplete
+	return r
+}
+
+func (r *TerminalReader) Prompt(prompt string) (string, error) {
+	r.t.SetPrompt(prompt)
+	line, err := r.t.ReadLine()
+	if err == term.ErrPasteIndicator {
+		err = nil
+	}
+	return line, err
+}
+
+func (r *TerminalReader) PasswordPrompt(prompt string) (string, error) {
+	return r.t.ReadPassword(prompt)
+}
+
+func (r *TerminalReader) SetCompleter(f func(line string) []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.completer = f
+}
+
+func (r *TerminalReader) setValuePicker(enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.valuePicker = enabled
+}
+
+// SetSize updates the size of the remote terminal.
+func (r *TerminalReader) SetSize(width, height int) error {
+	r.mu.Lock()
+	r.width, r.height = width, height
+	r.mu.Unlock()
+	return r.t.SetSize(width, height)
+}
+
+func (r *TerminalReader) terminalSize() (width, height int, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.width, r.height, r.height > 0
+}
+
+func (r *TerminalReader) Write(p []byte) (int, error) {
+	return r.t.Write(p)
+}
+
+func (r *TerminalReader) AppendHistory(item string) {
+	r.history.Add(item)
+}
+
+func (r *TerminalReader) ReadHistory(rd io.Reader) (int, error) {
+	var n int
+	s := bufio.NewScanner(rd)
+	for s.Scan() {
+		r.history.Add(s.Text())
+		n++
+	}
+	return n, s.Err()
+}
+
+func (r *TerminalReader) WriteHistory(w io.Writer) (int, error) {
+	var n int
+	for i := r.history.Len() - 1; i >= 0; i-- {
+		if _, err := io.WriteString(w, r.history.At(i)+"\n"); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (r *TerminalReader) Close() error {
+	return nil
+}
+
+func (r *TerminalReader) autoComplete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.completer == nil || pos != len(line) {
+		return "", 0, false
+	}
+
+	// continue cycling if the line wasn't changed since the last tab
+	if t := &r.tab; len(t.candidates) > 1 && line == t.last && r.valuePicker {
+		t.index = (t.index + 1) % len(t.candidates)
+		t.last = t.candidates[t.index]
+		return t.last, len(t.last), true
+	}
+
+	candidates := r.completer(line)
+	switch {
+	case len(candidates) == 0:
+		return "", 0, false
+	case len(candidates) == 1 || r.valuePicker:
+		r.tab = tabState{candidates: candidates, last: candidates[0]}
+		return candidates[0], len(candidates[0]), true
+	}
+	r.tab = tabState{}
+	// print the candidates and complete the common prefix
+	r.t.Write([]byte(strings.Join(candidates, "  ") + "\n"))
+	prefix := commonPrefix(candidates)
+	if len(prefix) <= len(line) {
+		return "", 0, false
+	}
+	return prefix, len(prefix), true
+}
+
+func commonPrefix(s []string) string {
+	prefix := s[0]
+	for _, v := range s[1:] {
+		for !strings.HasPrefix(v, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// history implements term.History, ignoring consecutive duplicates.
+type history struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+const maxHistory = 1000
+
+func (h *history) Add(entry string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if entry == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry) {
+		return
+	}
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+	}
+}
+
+func (h *history) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.entries)
+}
+
+func (h *history) At(idx int) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.entries[len(h.entries)-1-idx]
+}
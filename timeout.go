@@ -0,0 +1,62 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrCmdTimeout = errors.New("command timed out")
+
+// WithDefaultTimeout sets the timeout of commands without their own timeout.
+func WithDefaultTimeout(d time.Duration) Opts {
+	return func(c *Console) {
+		c.defaultTimeout = d
+	}
+}
+
+func (c *Console) cmdTimeout(cmd *Cmd) time.Duration {
+	if cmd.Timeout > 0 {
+		return cmd.Timeout
+	}
+	return c.defaultTimeout
+}
+
+// runHandler runs the handler of cmd and gives up once its timeout is exceeded.
+// The handler itself keeps running until it returns, it should stop once the context is done.
+func (c *Console) runHandler(ctx context.Context, cmd *Cmd, args []string) error {
+	timeout := c.cmdTimeout(cmd)
+	if timeout <= 0 {
+		return c.safeHandle(ctx, cmd, args)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	errC := make(chan error, 1)
+	panicC := make(chan any, 1)
+	go func() {
+		defer func() {
+			// only reached if panics are re-raised
+			if r := recover(); r != nil {
+				panicC <- r
+			}
+		}()
+		errC <- c.safeHandle(ctx, cmd, args)
+	}()
+
+	select {
+	case err := <-errC:
+		if errors.Is(err, context.DeadlineExceeded) && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%w after %s", ErrCmdTimeout, timeout)
+		}
+		return err
+	case r := <-panicC:
+		panic(r)
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%w after %s", ErrCmdTimeout, timeout)
+		}
+		return ctx.Err()
+	}
+}
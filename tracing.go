@@ -0,0 +1,43 @@
+package console
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/jon4hz/console"
+
+// WithTracerProvider starts a span for every executed command.
+// The span context is passed to the ContextHandler of the command,
+// so downstream calls join the trace.
+func WithTracerProvider(tp trace.TracerProvider) Opts {
+	return func(c *Console) {
+		c.tracer = tp.Tracer(tracerName)
+	}
+}
+
+func (c *Console) startSpan(ctx context.Context, cmd *Cmd, args []string) (context.Context, func(err error)) {
+	if c.tracer == nil {
+		return ctx, func(error) {}
+	}
+	ctx, span := c.tracer.Start(ctx, "console "+cmd.Name,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(
+			attribute.String("console.command.name", cmd.Name),
+			attribute.Int("console.command.arg_count", len(args)),
+		),
+	)
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			span.SetAttributes(attribute.String("console.command.exit_status", "error"))
+		} else {
+			span.SetAttributes(attribute.String("console.command.exit_status", "ok"))
+		}
+		span.End()
+	}
+}
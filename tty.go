@@ -0,0 +1,16 @@
+package console
+
+import (
+	"os"
+
+	"github.com/mattn/go-isatty"
+)
+
+// fileIsPipe reports whether in isn't an interactive terminal, like a pipe
+// or a redirected file, the same on all platforms. The terminals of Cygwin
+// and MSYS2 on Windows, like mintty and Git Bash, are pipes too, but used
+// interactively.
+func fileIsPipe(in *os.File) bool {
+	fd := in.Fd()
+	return !isatty.IsTerminal(fd) && !isatty.IsCygwinTerminal(fd)
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package console
+
+// setupTTY prepares the terminal attached to stdout. Unix terminals need no setup.
+func (c *Console) setupTTY() {}
+
+func (c *Console) clearScreen() {
+	c.clearScreenANSI()
+}
@@ -0,0 +1,61 @@
+//go:build windows
+
+package console
+
+import (
+	"os"
+	"unsafe"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-isatty"
+	"github.com/muesli/termenv"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32                       = windows.NewLazySystemDLL("kernel32.dll")
+	procFillConsoleOutputCharacter = kernel32.NewProc("FillConsoleOutputCharacterW")
+	procFillConsoleOutputAttribute = kernel32.NewProc("FillConsoleOutputAttribute")
+	procSetConsoleCursorPosition   = kernel32.NewProc("SetConsoleCursorPosition")
+)
+
+// setupTTY enables virtual terminal processing, so cmd.exe and PowerShell
+// render escape sequences instead of printing them. Consoles of Windows
+// before 10 don't support it, on them the output isn't styled.
+func (c *Console) setupTTY() {
+	if c.out != os.Stdout || !isatty.IsTerminal(os.Stdout.Fd()) {
+		return
+	}
+	mode, err := termenv.EnableWindowsANSIConsole()
+	if err != nil {
+		c.logger.Debug("virtual terminal processing not supported", "err", err)
+		c.legacyConsole = true
+		lipgloss.SetColorProfile(termenv.Ascii)
+		return
+	}
+	c.restoreTTY = func() {
+		if err := termenv.RestoreWindowsConsole(mode); err != nil {
+			c.logger.Error("error restoring console mode", "err", err)
+		}
+	}
+}
+
+// clearScreen clears legacy consoles with the console API.
+func (c *Console) clearScreen() {
+	if !c.legacyConsole {
+		c.clearScreenANSI()
+		return
+	}
+	h := windows.Handle(os.Stdout.Fd())
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(h, &info); err != nil {
+		c.logger.Error("error clearing the console", "err", err)
+		return
+	}
+	n := uintptr(info.Size.X) * uintptr(info.Size.Y)
+	var written uint32
+	// the coordinates are passed by value, 0 is the top left corner
+	procFillConsoleOutputCharacter.Call(uintptr(h), ' ', n, 0, uintptr(unsafe.Pointer(&written)))
+	procFillConsoleOutputAttribute.Call(uintptr(h), uintptr(info.Attributes), n, 0, uintptr(unsafe.Pointer(&written)))
+	procSetConsoleCursorPosition.Call(uintptr(h), 0)
+}
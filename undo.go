@@ -0,0 +1,85 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+type undoEntry struct {
+	desc string
+	fn   func() error
+}
+
+// RegisterUndo registers a function reverting the operation that was just performed.
+// The most recent registration is executed first by the undo command.
+func (c *Console) RegisterUndo(desc string, fn func() error) {
+	c.undoMu.Lock()
+	defer c.undoMu.Unlock()
+	c.undoStack = append(c.undoStack, undoEntry{desc: desc, fn: fn})
+}
+
+// Undo reverts the most recent operation.
+func (c *Console) Undo() error {
+	e, ok := c.popUndo()
+	if !ok {
+		return ErrNothingToUndo
+	}
+	return e.run()
+}
+
+// popUndo removes the most recent entry from the undo stack.
+func (c *Console) popUndo() (undoEntry, bool) {
+	c.undoMu.Lock()
+	defer c.undoMu.Unlock()
+	if len(c.undoStack) == 0 {
+		return undoEntry{}, false
+	}
+	e := c.undoStack[len(c.undoStack)-1]
+	c.undoStack = c.undoStack[:len(c.undoStack)-1]
+	return e, true
+}
+
+// run reverts the operation. It's called without holding undoMu,
+// so fn can register undo functions itself.
+func (e undoEntry) run() error {
+	if err := e.fn(); err != nil {
+		return fmt.Errorf("undo %s: %w", e.desc, err)
+	}
+	return nil
+}
+
+func undoView(c *Console) string {
+	c.undoMu.Lock()
+	defer c.undoMu.Unlock()
+	if len(c.undoStack) == 0 {
+		return c.msg(MsgNothingToUndo)
+	}
+	s := c.msg(MsgUndoStack)
+	for i := len(c.undoStack) - 1; i >= 0; i-- {
+		s += fmt.Sprintf("\n  %d. %s", len(c.undoStack)-i, c.undoStack[i].desc)
+	}
+	return s
+}
+
+var undoCmd = &Cmd{
+	Name:        "undo",
+	Description: "Undo the last operation",
+	descID:      MsgUndoDescription,
+	builtin:     true,
+	Args:        []*Arg{{Name: "action", Values: []string{"list"}}},
+	ContextHandler: func(ctx context.Context, c *Console, args []string) error {
+		if len(args) > 0 && args[0] == "list" {
+			fmt.Fprintln(c.Writer(ctx), undoView(c))
+			return nil
+		}
+		e, ok := c.popUndo()
+		if !ok {
+			return ErrNothingToUndo
+		}
+		fmt.Fprintln(c.Writer(ctx), c.msg(MsgUndoing, e.desc))
+		return e.run()
+	},
+}
@@ -0,0 +1,173 @@
+package console
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// attachHello is sent by Attach to request a terminal session.
+const attachHello = "\x00console-attach"
+
+// UnixSession creates the console of a unix socket connection.
+// The given options connect the console to the connection and must be passed to New
+// or Engine.NewSession. Use an Engine to share the commands between sessions.
+type UnixSession func(conn net.Conn, opts ...Opts) (*Console, error)
+
+// ListenUnix exposes consoles on a unix domain socket at path until ctx is canceled.
+// The permissions of the socket file control who can connect. An existing socket
+// at path is replaced unless another server is still listening on it. The identity of a
+// session is the user of the connected process on Linux and the remote address elsewhere.
+//
+// Clients using Attach get a terminal with line editing and completion,
+// other clients like `nc -U` are served line by line.
+func ListenUnix(ctx context.Context, path string, perm os.FileMode, newSession UnixSession) error {
+	if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return fmt.Errorf("%s is in use by another server", path)
+		}
+		// remove a stale socket of a previous run
+		os.Remove(path)
+	}
+	l, err := listenUnix(path, perm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		l.Close()
+		os.Remove(path)
+	}()
+
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go serveUnix(ctx, conn, newSession)
+	}
+}
+
+// listenUnix listens on path with the permissions perm. The socket is created
+// in a directory only accessible by the process and moved to path once its
+// permissions are set, so nobody can connect in between.
+func listenUnix(path string, perm os.FileMode) (*net.UnixListener, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".console-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, "socket")
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmp, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	// the socket is removed at path by ListenUnix
+	l.SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, perm); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("error setting socket permissions: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func serveUnix(ctx context.Context, conn net.Conn, newSession UnixSession) {
+	defer conn.Close()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// wait for the hello of Attach, without blocking plain clients waiting for the prompt
+	buf := make([]byte, 128)
+	conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+	n, _ := conn.Read(buf)
+	conn.SetReadDeadline(time.Time{})
+	br := bufio.NewReader(io.MultiReader(bytes.NewReader(buf[:n]), conn))
+	opts := []Opts{WithContext(ctx), WithHistoryFile(""), WithIdentity(unixIdentity(conn))}
+
+	if bytes.HasPrefix(buf[:n], []byte(attachHello)) {
+		line, _ := br.ReadString('\n')
+		tr := NewTerminalReader(struct {
+			io.Reader
+			io.Writer
+		}{br, conn})
+		var width, height int
+		if _, err := fmt.Sscanf(strings.TrimPrefix(line, attachHello), " %d %d", &width, &height); err == nil {
+			tr.SetSize(width, height)
+		}
+		opts = append(opts, WithLineReader(tr), WithOutput(tr))
+	} else {
+		opts = append(opts, WithLineReader(NewPlainReader(br, conn)), WithOutput(conn))
+	}
+
+	c, err := newSession(conn, opts...)
+	if err != nil {
+		fmt.Fprintln(conn, err)
+		return
+	}
+	defer c.Close()
+	if err := c.Start(); err != nil {
+		c.Println(err)
+	}
+}
+
+// unixIdentity returns the identity of the peer of conn. Where the peer
+// credentials aren't available, the user is named after the remote address.
+func unixIdentity(conn net.Conn) Identity {
+	if name, ok := peerUser(conn); ok {
+		return Identity{Name: name}
+	}
+	return Identity{Name: conn.RemoteAddr().String()}
+}
+
+// Attach connects the terminal to a console served by ListenUnix.
+func Attach(path string) error {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		go io.Copy(conn, os.Stdin)
+		_, err := io.Copy(os.Stdout, conn)
+		return err
+	}
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		width, height = 80, 24
+	}
+	if _, err := fmt.Fprintf(conn, "%s %d %d\n", attachHello, width, height); err != nil {
+		return err
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	go io.Copy(conn, os.Stdin)
+	_, err = io.Copy(os.Stdout, conn)
+	return err
+}
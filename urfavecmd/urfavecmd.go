@@ -0,0 +1,134 @@
+// Package urfavecmd runs the commands of a urfave/cli application in a console.
+//
+// Every visible top level command of the application becomes a console
+// command. Invocations run through the application, so flags, subcommands,
+// help output and actions behave like on the command line.
+package urfavecmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jon4hz/console"
+	"github.com/urfave/cli/v2"
+)
+
+// completionFlag makes urfave/cli print completion candidates instead of running the command.
+const completionFlag = "--generate-bash-completion"
+
+// adapter serializes the runs of the application, which writes its
+// output to the writers of the App.
+type adapter struct {
+	mu  sync.Mutex
+	app *cli.App
+}
+
+// FromApp converts the commands of app. The help command of the
+// application is skipped in favor of the help builtin.
+func FromApp(app *cli.App) []*console.Cmd {
+	a := &adapter{app: app}
+	var cmds []*console.Cmd
+	for _, cmd := range app.VisibleCommands() {
+		if cmd.Name == "help" {
+			continue
+		}
+		cmds = append(cmds, a.cmd(cmd))
+	}
+	return cmds
+}
+
+func (a *adapter) cmd(cmd *cli.Command) *console.Cmd {
+	return &console.Cmd{
+		Name:        cmd.Name,
+		Aliases:     cmd.Aliases,
+		Description: cmd.Usage,
+		// the flags are parsed by the application, which may define --output
+		ParsesOutputFlag: true,
+		Completer: func(_ *console.Console, args []string) []string {
+			return a.complete(cmd, args)
+		},
+		ContextHandler: func(ctx context.Context, c *console.Console, args []string) error {
+			w := c.Writer(ctx)
+			return a.run(ctx, w, append([]string{cmd.Name}, args...))
+		},
+	}
+}
+
+func (a *adapter) run(ctx context.Context, w io.Writer, args []string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	writer, errWriter, exitHandler := a.app.Writer, a.app.ErrWriter, a.app.ExitErrHandler
+	defer func() {
+		a.app.Writer, a.app.ErrWriter, a.app.ExitErrHandler = writer, errWriter, exitHandler
+	}()
+	a.app.Writer, a.app.ErrWriter = w, w
+	// errors are returned to the console instead of exiting the process
+	a.app.ExitErrHandler = func(*cli.Context, error) {}
+	return a.app.RunContext(ctx, append([]string{a.app.Name}, args...))
+}
+
+// complete completes flags of the innermost command from their
+// definitions and everything else with the completion of the application.
+func (a *adapter) complete(cmd *cli.Command, args []string) []string {
+	cur := args[len(args)-1]
+	// the line being completed is split on every space, repeated spaces leave empty words
+	var prev []string
+	for _, p := range args[:len(args)-1] {
+		if p != "" {
+			prev = append(prev, p)
+		}
+	}
+	inner := cmd
+	for _, p := range prev {
+		if sub := inner.Command(p); sub != nil {
+			inner = sub
+		}
+	}
+	if strings.HasPrefix(cur, "-") {
+		return withPrefix(flagNames(inner.VisibleFlags()), cur)
+	}
+	if !a.app.EnableBashCompletion {
+		var names []string
+		for _, sub := range inner.VisibleCommands() {
+			names = append(names, sub.Names()...)
+		}
+		return withPrefix(names, cur)
+	}
+	var buf bytes.Buffer
+	_ = a.run(context.Background(), &buf, append(append([]string{cmd.Name}, prev...), completionFlag))
+	var candidates []string
+	for _, l := range strings.Split(buf.String(), "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			candidates = append(candidates, l)
+		}
+	}
+	return withPrefix(candidates, cur)
+}
+
+func flagNames(flags []cli.Flag) []string {
+	var names []string
+	for _, f := range flags {
+		for _, n := range f.Names() {
+			if len(n) == 1 {
+				names = append(names, "-"+n)
+			} else {
+				names = append(names, "--"+n)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func withPrefix(values []string, prefix string) (s []string) {
+	for _, v := range values {
+		if strings.HasPrefix(v, prefix) {
+			s = append(s, v)
+		}
+	}
+	return
+}
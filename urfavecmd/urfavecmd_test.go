@@ -0,0 +1,78 @@
+package urfavecmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jon4hz/console"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v2"
+)
+
+func testApp() *cli.App {
+	return &cli.App{
+		Name:                 "tool",
+		EnableBashCompletion: true,
+		Commands: []*cli.Command{
+			{
+				Name:    "greet",
+				Aliases: []string{"g"},
+				Usage:   "Greet someone",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "greeting", Aliases: []string{"x"}, Value: "hello"},
+					&cli.BoolFlag{Name: "loud"},
+					&cli.StringFlag{Name: "output"},
+				},
+				BashComplete: func(c *cli.Context) {
+					fmt.Fprintln(c.App.Writer, "alice")
+					fmt.Fprintln(c.App.Writer, "bob")
+				},
+				Action: func(c *cli.Context) error {
+					fmt.Fprintf(c.App.Writer, "%s %s\n", c.String("greeting"), c.Args().First())
+					return nil
+				},
+			},
+			{
+				Name: "fail",
+				Action: func(*cli.Context) error {
+					return cli.Exit("failed", 3)
+				},
+			},
+			{Name: "secret", Hidden: true},
+		},
+	}
+}
+
+func TestFromApp(t *testing.T) {
+	cmds := FromApp(testApp())
+	assert.Len(t, cmds, 2)
+
+	var out bytes.Buffer
+	c, err := console.New(console.WithOutput(&out))
+	assert.NoError(t, err)
+	defer c.Close()
+	assert.NoError(t, c.RegisterCommands(cmds...))
+
+	assert.NoError(t, c.Run(context.Background(), "g --greeting hi alice"))
+	assert.Equal(t, "hi alice\n", out.String())
+	// --output is a flag of the application, not the output format
+	out.Reset()
+	assert.NoError(t, c.Run(context.Background(), "greet --output out.txt bob"))
+	assert.Equal(t, "hello bob\n", out.String())
+	// empty arguments are passed to the application
+	out.Reset()
+	assert.NoError(t, c.Run(context.Background(), `greet "" bob`))
+	assert.Equal(t, "hello \n", out.String())
+	assert.EqualError(t, c.Run(context.Background(), "fail"), "failed")
+	assert.Error(t, c.Run(context.Background(), "greet --bogus"))
+
+	out.Reset()
+	assert.NoError(t, c.Run(context.Background(), "greet --help"))
+	assert.Contains(t, out.String(), "Greet someone")
+
+	assert.Equal(t, []string{"--greeting"}, cmds[0].Completer(c, []string{"--g"}))
+	assert.Equal(t, []string{"bob"}, cmds[0].Completer(c, []string{"b"}))
+	assert.Equal(t, []string{"bob"}, cmds[0].Completer(c, []string{"", "b"}))
+}
@@ -0,0 +1,98 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// Version describes the build of the application embedding the console.
+type Version struct {
+	Name    string `json:"name" yaml:"name"`
+	Version string `json:"version" yaml:"version"`
+	Commit  string `json:"commit,omitempty" yaml:"commit,omitempty"`
+	Date    string `json:"date,omitempty" yaml:"date,omitempty"`
+}
+
+func (v Version) String() string {
+	s := strings.TrimSpace(v.Name + " " + v.Version)
+	var details []string
+	if v.Commit != "" {
+		details = append(details, "commit "+v.Commit)
+	}
+	if v.Date != "" {
+		details = append(details, "built "+v.Date)
+	}
+	if len(details) > 0 {
+		s += " (" + strings.Join(details, ", ") + ")"
+	}
+	return s
+}
+
+// WithVersion sets the version of the application and registers the
+// version builtin. The version is available in the welcome message as
+// template, e.g. "Welcome to {{.Name}} {{.Version}}". Welcome messages
+// which aren't valid templates are shown as they are.
+func WithVersion(name, version, commit, date string) Opts {
+	return func(c *Console) {
+		c.version = &Version{Name: name, Version: version, Commit: commit, Date: date}
+	}
+}
+
+// Version returns the version set with WithVersion.
+func (c *Console) Version() (Version, bool) {
+	if c.version == nil {
+		return Version{}, false
+	}
+	return *c.version, true
+}
+
+// welcomeTemplate parses the welcome message if it's a template.
+// If it isn't a valid template, it returns nil to show the message as text.
+func (c *Console) welcomeTemplate() *template.Template {
+	if !strings.Contains(c.welcomeMsg, "{{") {
+		return nil
+	}
+	t, err := template.New("welcome").Parse(c.welcomeMsg)
+	if err != nil {
+		c.logger.Debug("welcome message isn't a template", "err", err)
+		return nil
+	}
+	return t
+}
+
+func (c *Console) renderWelcomeMsg() string {
+	if c.welcomeTmpl == nil {
+		return c.welcomeMsg
+	}
+	v, _ := c.Version()
+	var b strings.Builder
+	if err := c.welcomeTmpl.Execute(&b, v); err != nil {
+		c.logger.Error("error rendering welcome message", "err", err)
+		return c.welcomeMsg
+	}
+	return b.String()
+}
+
+var versionCmd = &Cmd{
+	Name:        "version",
+	Description: "Show the version",
+	descID:      MsgVersionDescription,
+	builtin:     true,
+	ResultHandler: func(ctx context.Context, c *Console, args []string) (*Result, error) {
+		if len(args) > 0 {
+			return nil, errors.New(c.msg(MsgUsageError, "version [--output <format>]"))
+		}
+		v, _ := c.Version()
+		return NewResult(v).With(FormatText, renderVersion).With(FormatTable, renderVersion), nil
+	},
+}
+
+// renderVersion prints the version on one line.
+func renderVersion(w io.Writer, data any) error {
+	_, err := fmt.Fprintln(w, data.(Version))
+	return err
+}
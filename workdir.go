@@ -0,0 +1,162 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrNotDir = errors.New("not a directory")
+
+// WithWorkingDir sets the working directory of the session and registers
+// the cd and pwd builtins. An empty dir starts in the working directory of
+// the process, which is never changed by cd.
+func WithWorkingDir(dir string) Opts {
+	return func(c *Console) {
+		c.workDirCmds = true
+		if dir == "" {
+			return
+		}
+		abs, err := filepath.Abs(expandHome(dir))
+		if err != nil {
+			c.optErr = fmt.Errorf("invalid working directory: %w", err)
+			return
+		}
+		c.workDir = abs
+	}
+}
+
+// WithWorkingDirPrompt shows the working directory in the prompt, e.g. "app:~/src> ".
+func WithWorkingDirPrompt(show bool) Opts {
+	return func(c *Console) {
+		c.workDirPrompt = show
+	}
+}
+
+// WorkingDir returns the working directory of the session.
+// It defaults to the working directory of the process.
+func (c *Console) WorkingDir() string {
+	if c.workDir == "" {
+		if wd, err := os.Getwd(); err == nil {
+			return wd
+		}
+	}
+	return c.workDir
+}
+
+// Chdir changes the working directory of the session.
+func (c *Console) Chdir(dir string) error {
+	dir = c.ResolvePath(dir)
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%s: %w", dir, ErrNotDir)
+	}
+	c.prevWorkDir, c.workDir = c.WorkingDir(), dir
+	return nil
+}
+
+// ResolvePath returns the absolute path of path relative to the working
+// directory. A leading ~ is expanded to the home directory.
+func (c *Console) ResolvePath(path string) string {
+	path = expandHome(path)
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(c.WorkingDir(), path)
+}
+
+// CompleteFiles is a Completer completing the last argument as a file
+// relative to the working directory. Directories end with a separator.
+func CompleteFiles(c *Console, args []string) []string {
+	return c.completePath(args[len(args)-1], false)
+}
+
+// completePath returns the files starting with prefix. Hidden files are
+// only returned if prefix names them, like shells do.
+func (c *Console) completePath(prefix string, dirsOnly bool) (s []string) {
+	dir, base := filepath.Split(prefix)
+	entries, err := os.ReadDir(c.ResolvePath(dir))
+	if err != nil {
+		return nil
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		isDir := e.IsDir()
+		if e.Type()&os.ModeSymlink != 0 {
+			if fi, err := os.Stat(filepath.Join(c.ResolvePath(dir), name)); err == nil {
+				isDir = fi.IsDir()
+			}
+		}
+		if isDir {
+			name += string(filepath.Separator)
+		} else if dirsOnly {
+			continue
+		}
+		s = append(s, dir+name)
+	}
+	return
+}
+
+// dirPrompt inserts the working directory into prompt if enabled.
+func (c *Console) dirPrompt(prompt string) string {
+	if !c.workDirPrompt {
+		return prompt
+	}
+	wd := c.WorkingDir()
+	if home, err := os.UserHomeDir(); err == nil && (wd == home || strings.HasPrefix(wd, home+string(filepath.Separator))) {
+		wd = "~" + wd[len(home):]
+	}
+	i := len(strings.TrimRight(prompt, promptSuffix))
+	if i > 0 {
+		wd = ":" + wd
+	}
+	return prompt[:i] + wd + prompt[i:]
+}
+
+var cdCmd = &Cmd{
+	Name:        "cd",
+	Description: "Change the working directory",
+	descID:      MsgCdDescription,
+	builtin:     true,
+	Args:        []*Arg{{Name: "dir", Description: "Directory, - for the previous one, defaults to the home directory"}},
+	Completer: func(c *Console, args []string) []string {
+		if len(args) > 1 {
+			return nil
+		}
+		return c.completePath(args[0], true)
+	},
+	Handler: func(c *Console, args []string) error {
+		switch {
+		case len(args) > 1:
+			return errors.New(c.msg(MsgUsageError, "cd [dir]"))
+		case len(args) == 0:
+			return c.Chdir("~")
+		case args[0] == "-":
+			if c.prevWorkDir == "" {
+				return errors.New(c.msg(MsgNoPreviousDir))
+			}
+			return c.Chdir(c.prevWorkDir)
+		}
+		return c.Chdir(args[0])
+	},
+}
+
+var pwdCmd = &Cmd{
+	Name:        "pwd",
+	Description: "Print the working directory",
+	descID:      MsgPwdDescription,
+	builtin:     true,
+	ContextHandler: func(ctx context.Context, c *Console, args []string) error {
+		fmt.Fprintln(c.Writer(ctx), c.WorkingDir())
+		return nil
+	},
+}
@@ -0,0 +1,173 @@
+// Package wsconsole serves a console over WebSockets, e.g. for xterm.js based web terminals.
+//
+// The client sends the terminal input as binary or text messages. Text messages
+// containing a JSON control message are interpreted instead:
+//
+//	{"type": "resize", "cols": 80, "rows": 24}
+//	{"type": "input", "data": "help\r"}
+//
+// The terminal output is sent as binary messages, or as text messages split at
+// UTF-8 character boundaries if TextFrames is set.
+package wsconsole
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+	"github.com/jon4hz/console"
+)
+
+// NewSession creates the console of a WebSocket connection.
+// The options must be passed to console.New or console.Engine.NewSession.
+type NewSession func(r *http.Request, opts ...console.Opts) (*console.Console, error)
+
+// Handler upgrades requests to WebSocket connections and runs a console for each of them.
+type Handler struct {
+	NewSession NewSession
+	Upgrader   websocket.Upgrader
+	// TextFrames sends the output as text instead of binary messages.
+	TextFrames bool
+	// Identity returns the identity of the user of a request, e.g. set by
+	// an authenticating middleware. By default, the user is named after
+	// the remote address of the request.
+	Identity func(r *http.Request) console.Identity
+}
+
+type controlMsg struct {
+	Type string `json:"type"`
+	Cols int    `json:"cols"`
+	Rows int    `json:"rows"`
+	Data string `json:"data"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ws, err := h.Upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader already replied with an error
+		return
+	}
+	defer ws.Close()
+
+	conn := &conn{ws: ws, text: h.TextFrames}
+	tr := console.NewTerminalReader(conn)
+	conn.onResize = func(cols, rows int) { tr.SetSize(cols, rows) }
+
+	id := console.Identity{Name: r.RemoteAddr}
+	if h.Identity != nil {
+		id = h.Identity(r)
+	}
+	c, err := h.NewSession(r,
+		console.WithLineReader(tr),
+		console.WithOutput(tr),
+		console.WithContext(r.Context()),
+		console.WithHistoryFile(""),
+		console.WithIdentity(id),
+	)
+	if err != nil {
+		ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, err.Error()))
+		return
+	}
+	defer c.Close()
+	if err := c.Start(); err != nil {
+		c.Println(err)
+	}
+	ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+}
+
+// conn adapts a WebSocket connection to an io.ReadWriter.
+type conn struct {
+	ws       *websocket.Conn
+	r        io.Reader
+	onResize func(cols, rows int)
+
+	mu      sync.Mutex
+	text    bool
+	pending []byte
+}
+
+func (c *conn) Read(p []byte) (int, error) {
+	for {
+		if c.r != nil {
+			n, err := c.r.Read(p)
+			if err == io.EOF {
+				c.r = nil
+				if n == 0 {
+					continue
+				}
+				err = nil
+			}
+			return n, err
+		}
+		typ, data, err := c.ws.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				return 0, io.EOF
+			}
+			return 0, err
+		}
+		if typ == websocket.TextMessage {
+			data = c.control(data)
+		}
+		c.r = bytes.NewReader(data)
+	}
+}
+
+// control handles a control message and returns the terminal input it contains.
+func (c *conn) control(data []byte) []byte {
+	if len(data) == 0 || data[0] != '{' {
+		return data
+	}
+	var msg controlMsg
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return data
+	}
+	switch msg.Type {
+	case "resize":
+		if c.onResize != nil && msg.Cols > 0 && msg.Rows > 0 {
+			c.onResize(msg.Cols, msg.Rows)
+		}
+		return nil
+	case "input":
+		return []byte(msg.Data)
+	}
+	return data
+}
+
+func (c *conn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.text {
+		if err := c.ws.WriteMessage(websocket.BinaryMessage, p); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	// text messages must be valid UTF-8, keep incomplete characters for the next write
+	data, rest := splitUTF8(append(c.pending, p...))
+	c.pending = append([]byte(nil), rest...)
+	if len(data) > 0 {
+		if err := c.ws.WriteMessage(websocket.TextMessage, bytes.ToValidUTF8(data, []byte("\uFFFD"))); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// splitUTF8 splits an incomplete character at the end of data.
+func splitUTF8(data []byte) ([]byte, []byte) {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return data[:i], data[i:]
+			}
+			break
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,79 @@
+package wsconsole
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/jon4hz/console"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	users := make(chan string, 1)
+	srv := httptest.NewServer(&Handler{
+		TextFrames: true,
+		NewSession: func(r *http.Request, opts ...console.Opts) (*console.Console, error) {
+			c, err := console.New(opts...)
+			if err == nil {
+				users <- c.Identity().Name
+			}
+			return c, err
+		},
+	})
+	defer srv.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	assert.NoError(t, err)
+	defer ws.Close()
+
+	assert.NoError(t, ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"resize","cols":120,"rows":40}`)))
+	assert.NoError(t, ws.WriteMessage(websocket.TextMessage, []byte("help\r")))
+	assert.NoError(t, ws.WriteMessage(websocket.BinaryMessage, []byte("quit\r")))
+
+	var out strings.Builder
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			break
+		}
+		out.Write(data)
+	}
+	assert.Contains(t, out.String(), "Available commands:")
+	assert.True(t, strings.HasPrefix(<-users, "127.0.0.1:"))
+}
+
+func TestHandlerIdentity(t *testing.T) {
+	users := make(chan string, 1)
+	srv := httptest.NewServer(&Handler{
+		Identity: func(r *http.Request) console.Identity {
+			return console.Identity{Name: r.Header.Get("X-User")}
+		},
+		NewSession: func(r *http.Request, opts ...console.Opts) (*console.Console, error) {
+			c, err := console.New(opts...)
+			if err == nil {
+				users <- c.Identity().Name
+			}
+			return c, err
+		},
+	})
+	defer srv.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), http.Header{"X-User": {"alice"}})
+	assert.NoError(t, err)
+	defer ws.Close()
+	assert.Equal(t, "alice", <-users)
+}
+
+func TestSplitUTF8(t *testing.T) {
+	euro := []byte("€")
+	data, rest := splitUTF8(append([]byte("a"), euro[:2]...))
+	assert.Equal(t, []byte("a"), data)
+	assert.Equal(t, euro[:2], rest)
+
+	data, rest = splitUTF8(append(rest, euro[2]))
+	assert.Equal(t, euro, data)
+	assert.Empty(t, rest)
+}